@@ -0,0 +1,94 @@
+package neotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+//Assertion result of an assertion command
+type Assertion struct {
+	File     string      `json:"file"`
+	Line     int         `json:"line"`
+	Cmd      string      `json:"cmd"`
+	Pass     bool        `json:"pass"`
+	Actual   interface{} `json:"actual"`
+	Expected interface{} `json:"expected"`
+	Message  string      `json:"message"`
+}
+
+func (a *Assertion) String() string {
+	status := "pass"
+	if !a.Pass {
+		status = "fail"
+	}
+	if a.File != "" {
+		return fmt.Sprintf("%v:%v: %v %v: %v", a.File, a.Line, a.Cmd, status, a.Message)
+	}
+	return fmt.Sprintf("line %v: %v %v: %v", a.Line, a.Cmd, status, a.Message)
+}
+
+//AssertError error returned by a failed assertion
+type AssertError struct {
+	*Assertion
+}
+
+func (e *AssertError) Error() string {
+	return fmt.Sprintf("assert fail: %v", e.Message)
+}
+
+//Assert record the result of assertion, an AssertError is returned if it's not pass
+func (vm *VM) Assert(cmd Commander, pass bool, actual, expected interface{}, message string) error {
+	a := &Assertion{
+		File:     vm.File,
+		Line:     cmd.Line(),
+		Cmd:      cmd.Name(),
+		Pass:     pass,
+		Actual:   actual,
+		Expected: expected,
+		Message:  message,
+	}
+	vm.assertions = append(vm.assertions, a)
+	if step := vm.curStep(); step != nil {
+		step.Assertions = append(step.Assertions, a)
+	}
+	if !pass {
+		return &AssertError{a}
+	}
+	return nil
+}
+
+//Assertions all assertions recorded by vm
+func (vm *VM) Assertions() []*Assertion {
+	return vm.assertions
+}
+
+//Failures failed assertions recorded by vm
+func (vm *VM) Failures() []*Assertion {
+	var failures []*Assertion
+	for _, a := range vm.assertions {
+		if !a.Pass {
+			failures = append(failures, a)
+		}
+	}
+	return failures
+}
+
+//valueEqual check whether two values are equal, numbers are compared by value regardless of go type
+func valueEqual(x, y interface{}) bool {
+	fx, xNum := toNumber(x)
+	fy, yNum := toNumber(y)
+	if xNum && yNum {
+		return fx == fy
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+//formatValue format value in assertion message
+func formatValue(v interface{}) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(bs)
+}
@@ -0,0 +1,126 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVM_Assert(t *testing.T) {
+	cmds, err := newSourceByBytes([]byte(`equal 1 1
+equal "a" "b"
+equal 2 2`)).Parse()
+	assert.NoError(t, err)
+
+	vm := NewVM(cmds)
+	vm.File = "a.ntf"
+	err = vm.Run()
+	assert.Error(t, err)
+	_, ok := err.(*ExecError).Err.(*AssertError)
+	assert.True(t, ok)
+
+	assert.Equal(t, 2, len(vm.Assertions()))
+	failures := vm.Failures()
+	assert.Equal(t, 1, len(failures))
+	assert.Equal(t, "a.ntf", failures[0].File)
+	assert.Equal(t, 2, failures[0].Line)
+	assert.Equal(t, "a", failures[0].Actual)
+	assert.Equal(t, "b", failures[0].Expected)
+	assert.Equal(t, `a.ntf:2: equal fail: "a" != "b"`, failures[0].String())
+}
+
+func TestValueEqual(t *testing.T) {
+	assert.True(t, valueEqual(200, 200.0))
+	assert.True(t, valueEqual([]interface{}{"a"}, []interface{}{"a"}))
+	assert.False(t, valueEqual("1", 1.0))
+	assert.False(t, valueEqual(true, "true"))
+}
+
+func TestAssertCmd_Fail(t *testing.T) {
+	var failed = []string{
+		`not-equal 1 1`,
+		`contains "abc" "d"`,
+		`contains ["a"] "b"`,
+		`contains {"a": 1} "b"`,
+		`match "abc" "^b"`,
+		`gt 1 2`,
+		`lt 2 2`,
+		`ge 1 2`,
+		`le 3 2`,
+		`exists $(resp.body)`,
+		`not-exists $(neotest.version)`,
+		`len-equal [1, 2] 1`,
+		`type-is 1 "string"`,
+	}
+	for _, text := range failed {
+		cmds, err := newSourceByBytes([]byte(text)).Parse()
+		assert.NoError(t, err)
+
+		vm := NewVM(cmds)
+		err = vm.Run()
+		assert.Error(t, err)
+		assert.Equal(t, 1, len(vm.Failures()))
+	}
+
+	var invalid = []string{
+		`not-equal 1`,
+		`match "abc" "("`,
+		`match "abc" 1`,
+		`gt "a" 1`,
+		`exists "a"`,
+		`len-equal [1] "a"`,
+		`type-is 1 "int"`,
+		`contains @a 1`,
+	}
+	for _, text := range invalid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestMatchCmd_Resp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "neo-go", "height": 1}`))
+	}))
+	defer server.Close()
+
+	cmds, err := newSourceByBytes([]byte(`req "GET" "` + server.URL + `"
+ret
+match $(resp.body.name) "^neo"
+match $(resp.body.height) "1"`)).Parse()
+	assert.NoError(t, err)
+
+	//type of internal variable is checked at runtime
+	vm := NewVM(cmds)
+	err = vm.Run()
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(vm.Assertions()))
+	assert.True(t, vm.Assertions()[0].Pass)
+}
+
+func TestPrefixAssertCmd(t *testing.T) {
+	cmds, err := newSourceByBytes([]byte("expect equal 1 2\nexpect gt 2 1\nlet @a 1\nassert equal 1 2\nlet @b 1")).Parse()
+	assert.NoError(t, err)
+
+	vm := NewVM(cmds)
+	err = vm.Run()
+	assert.Error(t, err)
+	assert.Equal(t, 3, len(vm.Assertions()))
+	assert.Equal(t, 2, len(vm.Failures()))
+	_, exist := vm.Var("a")
+	assert.True(t, exist)
+	_, exist = vm.Var("b")
+	assert.False(t, exist)
+
+	var invalid = []string{
+		`expect let @a 1`,
+		`assert`,
+		`expect equal 1`,
+		`expect expect equal 1 1`,
+	}
+	for _, text := range invalid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
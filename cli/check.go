@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/pkg/pln"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+func newCheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check <files>",
+		Short: "Parse and check ntf files without executing, every error is reported",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			files, err := discoverFiles(args)
+			if err != nil {
+				pln.Error(err)
+				os.Exit(1)
+			}
+			if n := checkFiles(files, os.Stdout); n > 0 {
+				pln.Error(fmt.Errorf("%v errors found", n))
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+//checkFiles print errors of files as 'file:line:column: message' and return the number of errors
+func checkFiles(files []string, w io.Writer) int {
+	var n int
+	for _, file := range files {
+		src, err := neotest.NewSource(file)
+		if err != nil {
+			fmt.Fprintf(w, "%v: %v\n", file, err)
+			n++
+			continue
+		}
+		for _, e := range src.Check() {
+			fmt.Fprintln(w, formatParseError(file, e))
+			n++
+		}
+	}
+	return n
+}
+
+func formatParseError(file string, e *neotest.ParseError) string {
+	if e.File != "" {
+		file = e.File
+	}
+	pos := fmt.Sprintf("%v:%v", file, e.Line)
+	if e.Column > 0 {
+		pos = fmt.Sprintf("%v:%v", pos, e.Column)
+	}
+	return fmt.Sprintf("%v: %v", pos, e.Err)
+}
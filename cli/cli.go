@@ -0,0 +1,312 @@
+//Package cli command line of neotest, it's shared by the neotest binary and custom binaries
+//which embed extra commands
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/pkg/pln"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	verbose    bool
+	report     string
+	reportOut  string
+	htmlReport string
+	parallel   int
+	keepGoing  bool
+	tags       []string
+	skipTags   []string
+	runPattern string
+)
+
+//Execute run neotest command line. A custom binary can register extra commands by
+//neotest.RegisterCommand before calling it
+func Execute() {
+	root := &cobra.Command{
+		Use:   "neotest",
+		Short: "An auto tool for testing neo transaction",
+		//files are passed as arguments besides sub commands
+		Args: cobra.ArbitraryArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				return
+			}
+			err := run(args)
+			if err != nil {
+				pln.Error(err)
+				os.Exit(1)
+			}
+		},
+	}
+	root.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose")
+	root.Flags().StringVar(&report, "report", "", "Report format: "+strings.Join(reportFormats, "|"))
+	root.Flags().StringVar(&reportOut, "report-out", "", "Report file, default is stdout")
+	root.Flags().IntVarP(&parallel, "parallel", "p", 1, "Number of files running concurrently")
+	root.Flags().BoolVarP(&keepGoing, "keep-going", "k", false, "Keep running the other files after a file fails")
+	root.Flags().StringSliceVar(&tags, "tags", nil, "Only run files with one of the tags, e.g. smoke,nep5")
+	root.Flags().StringSliceVar(&skipTags, "skip-tags", nil, "Skip files with one of the tags")
+	root.Flags().StringVar(&runPattern, "run", "", "Only run files and cases whose name matches the regular expression")
+	root.Flags().StringVar(&htmlReport, "html-report", "", "Write a static html report with executed commands, txs and http/rpc exchanges to the file")
+
+	root.AddCommand(newCheckCmd())
+	root.AddCommand(newFmtCmd())
+	root.AddCommand(newReplCmd())
+	root.AddCommand(newDebugCmd())
+	root.Execute()
+}
+
+func run(args []string) (err error) {
+	pln.Verbose = verbose
+	if report != "" && !containsString(reportFormats, report) {
+		return fmt.Errorf("unknown report format %v, should be one of %v", report, strings.Join(reportFormats, ", "))
+	}
+	files, err := discoverFiles(args)
+	if err != nil {
+		return err
+	}
+	filter, err := newFileFilter(tags, skipTags, runPattern)
+	if err != nil {
+		return err
+	}
+
+	var results []*FileResult
+	var assertions []*neotest.Assertion
+	var cases []*neotest.CaseResult
+	defer func() {
+		printSummary(results, assertions, cases)
+		var reportErr error
+		if report != "" {
+			reportErr = writeReport(report, reportOut, results)
+		}
+		if htmlReport != "" && reportErr == nil {
+			reportErr = writeHTMLReport(htmlReport, results)
+		}
+		if err == nil {
+			err = reportErr
+		}
+	}()
+
+	all, errs := runFiles(files, filter, parallel, keepGoing)
+	var fileErrs []error
+	for i, r := range all {
+		//skipped by filter or after failure
+		if r == nil {
+			if errs[i] != nil {
+				fileErrs = append(fileErrs, errs[i])
+			}
+			continue
+		}
+		results = append(results, r)
+		assertions = append(assertions, r.Assertions...)
+		cases = append(cases, r.Cases...)
+		if errs[i] != nil {
+			fileErrs = append(fileErrs, errs[i])
+		}
+	}
+
+	if len(results) == 0 && len(fileErrs) == 0 {
+		pln.Warn("no file or case to run")
+		return nil
+	}
+
+	switch {
+	case len(fileErrs) == 1:
+		return fileErrs[0]
+	case len(fileErrs) > 1:
+		for _, e := range fileErrs {
+			pln.Error(e)
+		}
+		return fmt.Errorf("%v of %v files failed", len(fileErrs), len(files))
+	}
+
+	failedCases := countFailedCases(cases)
+	if failedCases > 0 {
+		return fmt.Errorf("%v of %v cases failed", failedCases, len(cases))
+	}
+	failed := countFailures(assertions)
+	if failed > 0 {
+		return fmt.Errorf("%v of %v assertions failed", failed, len(assertions))
+	}
+	return nil
+}
+
+//runFiles run files by n workers, each file runs in its own VM and the results are in order of files.
+//Output of files is buffered and printed once a file is done if n > 1. The results of files
+//skipped by filter, or after the first failed file unless keepGoing, are nil
+func runFiles(files []string, filter *fileFilter, n int, keepGoing bool) ([]*FileResult, []error) {
+	if n < 1 {
+		n = 1
+	}
+	results := make([]*FileResult, len(files))
+	errs := make([]error, len(files))
+
+	var mu sync.Mutex
+	var failed bool
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				skip := failed && !keepGoing
+				mu.Unlock()
+				if skip {
+					continue
+				}
+
+				var out io.Writer = os.Stdout
+				var buf *bytes.Buffer
+				if n > 1 {
+					buf = new(bytes.Buffer)
+					out = buf
+				}
+				r, err := runFile(files[i], filter, out)
+
+				mu.Lock()
+				results[i], errs[i] = r, err
+				failed = failed || err != nil
+				if buf != nil && r != nil {
+					fmt.Fprintf(os.Stdout, "=== %v (%v)\n", files[i], r.Duration)
+					os.Stdout.Write(buf.Bytes())
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs
+}
+
+//runFile parse and run a ntf file, the returned error describes which stage is failed.
+//The result is nil if the file is skipped by filter.
+func runFile(file string, filter *fileFilter, out io.Writer) (*FileResult, error) {
+	r := &FileResult{File: file}
+	start := time.Now()
+	defer func() {
+		r.Duration = time.Since(start)
+	}()
+
+	src, err := neotest.NewSource(file)
+	if err != nil {
+		r.Err = err
+		return r, err
+	}
+	if !filter.matchTags(src.Tags()) {
+		return nil, nil
+	}
+	commands, err := src.Parse()
+	if err != nil {
+		r.Err = err
+		return r, fmt.Errorf("parse %v err: %v", file, err)
+	}
+	caseFilter, skip := filter.caseFilter(file, commands)
+	if skip {
+		return nil, nil
+	}
+	vm := neotest.NewVM(commands)
+	vm.CaseFilter = caseFilter
+	vm.File = file
+	vm.Out = out
+	vm.Trace = htmlReport != ""
+	r.Err = vm.Run()
+	r.Assertions = vm.Assertions()
+	r.Cases = vm.Cases()
+	r.TxHashes = vm.TxHashes()
+	r.Steps = vm.Steps()
+	if r.Err != nil {
+		return r, fmt.Errorf("run %v err: %v", file, r.Err)
+	}
+	return r, nil
+}
+
+//printSummary print result of files, cases, failed assertions and the count
+func printSummary(results []*FileResult, assertions []*neotest.Assertion, cases []*neotest.CaseResult) {
+	if len(results) > 1 {
+		var failed int
+		for _, r := range results {
+			if !r.Pass() {
+				failed++
+			}
+		}
+		if failed > 0 {
+			pln.InfoFail("files: %v, passed: %v, failed: %v", len(results), len(results)-failed, failed)
+		} else {
+			pln.InfoSuccess("files: %v, passed: %v, failed: %v", len(results), len(results)-failed, failed)
+		}
+	}
+	for _, c := range cases {
+		if c.Pass() {
+			pln.InfoSuccess("case %v:%v %v pass (%v)", c.File, c.Line, c.Name, c.Duration)
+		} else {
+			pln.InfoFail("case %v:%v %v fail (%v): %v", c.File, c.Line, c.Name, c.Duration, c.Err)
+		}
+	}
+	if len(cases) > 0 {
+		failed := countFailedCases(cases)
+		if failed > 0 {
+			pln.InfoFail("cases: %v, passed: %v, failed: %v", len(cases), len(cases)-failed, failed)
+		} else {
+			pln.InfoSuccess("cases: %v, passed: %v, failed: %v", len(cases), len(cases)-failed, failed)
+		}
+	}
+
+	if len(assertions) == 0 {
+		return
+	}
+
+	for _, a := range assertions {
+		if !a.Pass {
+			pln.InfoFail("%v", a)
+		}
+	}
+
+	failed := countFailures(assertions)
+	if failed > 0 {
+		pln.InfoFail("assertions: %v, passed: %v, failed: %v", len(assertions), len(assertions)-failed, failed)
+	} else {
+		pln.InfoSuccess("assertions: %v, passed: %v, failed: %v", len(assertions), len(assertions)-failed, failed)
+	}
+}
+
+func countFailures(assertions []*neotest.Assertion) int {
+	var n int
+	for _, a := range assertions {
+		if !a.Pass {
+			n++
+		}
+	}
+	return n
+}
+
+func countFailedCases(cases []*neotest.CaseResult) int {
+	var n int
+	for _, c := range cases {
+		if !c.Pass() {
+			n++
+		}
+	}
+	return n
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,474 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/hzxiao/goutil/assert"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/pkg/neo"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init()  {
+	verbose = true
+}
+
+func TestRun(t *testing.T)  {
+	err := run([]string{"../testdata/echo.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestLetCmd(t *testing.T)  {
+	err := run([]string{"../testdata/let.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestSubCmd(t *testing.T)  {
+	err := run([]string{"../testdata/sub_cmd.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestEqualCmd(t *testing.T)  {
+	err := run([]string{"../testdata/equal.ntf"})
+	assert.Error(t, err)
+}
+
+func TestReqCmd(t *testing.T)  {
+	var hello = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write([]byte("hi"))
+	}
+
+	var foo = func(w http.ResponseWriter, r *http.Request) {
+		buf, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		defer r.Body.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		w.Write(buf)
+	}
+
+	http.HandleFunc("/hello", hello)
+	http.HandleFunc("/foo", foo)
+
+	go func() {
+		err := http.ListenAndServe(":10000", nil)
+		assert.Error(t, err)
+	}()
+
+	time.Sleep(2*time.Second)
+	err := run([]string{"../testdata/http.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestTx(t *testing.T)  {
+	err := run([]string{"../testdata/tx.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestNep5(t *testing.T)  {
+	err := run([]string{"../testdata/nep5.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestIfCmd(t *testing.T) {
+	err := run([]string{"../testdata/if.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestLoopCmd(t *testing.T) {
+	err := run([]string{"../testdata/loop.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestProcCmd(t *testing.T) {
+	err := run([]string{"../testdata/proc.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestIncludeCmd(t *testing.T) {
+	err := run([]string{"../testdata/include.ntf"})
+	assert.NoError(t, err)
+
+	err = run([]string{"../testdata/include/cycle_a.ntf"})
+	assert.Error(t, err)
+
+	err = run([]string{"../testdata/include_fail.ntf"})
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "include/fail.ntf: line 2"))
+}
+
+func TestArithExpr(t *testing.T) {
+	err := run([]string{"../testdata/arith.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestCollection(t *testing.T) {
+	err := run([]string{"../testdata/collection.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestAssertCmd(t *testing.T) {
+	err := run([]string{"../testdata/assert.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestExpectCmd(t *testing.T) {
+	err := run([]string{"../testdata/expect.ntf"})
+	assert.Error(t, err)
+	assert.Equal(t, "2 of 4 assertions failed", err.Error())
+}
+
+func TestCaseCmd(t *testing.T) {
+	err := run([]string{"../testdata/case.ntf"})
+	assert.Error(t, err)
+	assert.Equal(t, "1 of 4 cases failed", err.Error())
+}
+
+func TestReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "neotest")
+	assert.NoError(t, err)
+
+	defer func() {
+		report, reportOut = "", ""
+		os.RemoveAll(dir)
+	}()
+
+	report, reportOut = "json", dir+"/report.json"
+	err = run([]string{"../testdata/case.ntf", "../testdata/arith.ntf"})
+	assert.Error(t, err)
+	bs, err := ioutil.ReadFile(reportOut)
+	assert.NoError(t, err)
+	var m struct {
+		Pass  bool
+		Files []struct {
+			File  string
+			Cases []struct {
+				Name string
+				Pass bool
+			}
+		}
+	}
+	assert.NoError(t, json.Unmarshal(bs, &m))
+	assert.False(t, m.Pass)
+	assert.Equal(t, 2, len(m.Files))
+	assert.Equal(t, 4, len(m.Files[0].Cases))
+	assert.Equal(t, "failed", m.Files[0].Cases[2].Name)
+	assert.False(t, m.Files[0].Cases[2].Pass)
+	assert.Equal(t, "../testdata/arith.ntf", m.Files[1].Cases[0].Name)
+
+	report, reportOut = "junit", dir+"/report.xml"
+	err = run([]string{"../testdata/expect.ntf"})
+	assert.Error(t, err)
+	bs, err = ioutil.ReadFile(reportOut)
+	assert.NoError(t, err)
+	var suites junitSuites
+	assert.NoError(t, xml.Unmarshal(bs, &suites))
+	assert.Equal(t, 1, len(suites.Suites))
+	assert.Equal(t, 1, suites.Suites[0].Failures)
+	assert.True(t, strings.Contains(suites.Suites[0].Cases[0].Failure.Text, "expect.ntf:4: contains fail"))
+
+	report, reportOut = "tap", dir+"/report.tap"
+	err = run([]string{"../testdata/case.ntf"})
+	assert.Error(t, err)
+	bs, err = ioutil.ReadFile(reportOut)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(bs), "TAP version 13\n1..4\n"))
+	assert.True(t, strings.Contains(string(bs), "not ok 3 - ../testdata/case.ntf: failed"))
+
+	report = "xml"
+	err = run([]string{"../testdata/case.ntf"})
+	assert.Error(t, err)
+}
+
+func TestWriteJUnit_NoFailureLine(t *testing.T) {
+	//error of assertion which is not in unit is still reported
+	a := &neotest.Assertion{File: "a.ntf", Line: 1, Message: "equal fail"}
+	r := &FileResult{File: "a.ntf", Cases: []*neotest.CaseResult{
+		{Name: "a", File: "a.ntf", Err: &neotest.AssertError{Assertion: a}},
+	}}
+	var buf bytes.Buffer
+	assert.NoError(t, writeJUnit(&buf, []*FileResult{r}))
+	var suites junitSuites
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 1, suites.Suites[0].Failures)
+	assert.Equal(t, "assert fail: equal fail", suites.Suites[0].Cases[0].Failure.Message)
+}
+
+func TestHTMLReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "neotest")
+	assert.NoError(t, err)
+
+	defer func() {
+		htmlReport = ""
+		os.RemoveAll(dir)
+	}()
+
+	htmlReport = dir + "/report.html"
+	err = run([]string{"../testdata/case.ntf"})
+	assert.Error(t, err)
+	bs, err := ioutil.ReadFile(htmlReport)
+	assert.NoError(t, err)
+	html := string(bs)
+	assert.True(t, strings.Contains(html, "after failed"))
+	assert.True(t, strings.Contains(html, "<code>let @amount 3</code>"))
+	assert.True(t, strings.Contains(html, `<tr class="failed">`))
+	assert.True(t, strings.Contains(html, "equal fail: 10 != 2"))
+}
+
+func TestRunFiles(t *testing.T) {
+	files := []string{"../testdata/include_fail.ntf", "../testdata/arith.ntf", "../testdata/case.ntf"}
+
+	results, errs := runFiles(files, &fileFilter{}, 1, false)
+	assert.Error(t, errs[0])
+	assert.True(t, results[1] == nil)
+	assert.True(t, results[2] == nil)
+
+	results, errs = runFiles(files, &fileFilter{}, 1, true)
+	assert.Error(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Equal(t, 4, len(results[1].Assertions))
+	assert.Equal(t, 4, len(results[2].Cases))
+
+	results, errs = runFiles(files, &fileFilter{}, 3, true)
+	assert.Error(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.NoError(t, errs[2])
+	assert.Equal(t, 4, len(results[1].Assertions))
+	assert.Equal(t, 4, len(results[2].Cases))
+}
+
+func TestParallel(t *testing.T) {
+	defer func() {
+		parallel, keepGoing = 0, false
+	}()
+
+	parallel, keepGoing = 4, true
+	err := run([]string{"../testdata/loop.ntf", "../testdata/arith.ntf", "../testdata/collection.ntf", "../testdata/assert.ntf"})
+	assert.NoError(t, err)
+
+	err = run([]string{"../testdata/include_fail.ntf", "../testdata/expect.ntf", "../testdata/include/fail.ntf"})
+	assert.Error(t, err)
+	assert.Equal(t, "2 of 3 files failed", err.Error())
+}
+
+func TestDiscoverFiles(t *testing.T) {
+	files, err := discoverFiles([]string{"../testdata/tags"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"../testdata/tags/smoke.ntf", "../testdata/tags/sub/slow.ntf"}, files)
+
+	files, err = discoverFiles([]string{"../testdata/tags/*.ntf", "../testdata/tags/sub", "../testdata/tags/smoke.ntf"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"../testdata/tags/smoke.ntf", "../testdata/tags/sub/slow.ntf"}, files)
+
+	_, err = discoverFiles([]string{"../testdata/tags/*.txt"})
+	assert.Error(t, err)
+	_, err = discoverFiles([]string{"../testdata/none.ntf"})
+	assert.Error(t, err)
+}
+
+func TestFilter(t *testing.T) {
+	defer func() {
+		tags, skipTags, runPattern = nil, nil, ""
+	}()
+	files := []string{"../testdata/tags"}
+
+	var count = func(args []string) (int, int) {
+		filter, err := newFileFilter(tags, skipTags, runPattern)
+		assert.NoError(t, err)
+		all, err := discoverFiles(args)
+		assert.NoError(t, err)
+		results, _ := runFiles(all, filter, 1, true)
+		var n, cases int
+		for _, r := range results {
+			if r != nil {
+				n++
+				cases += len(r.Cases)
+			}
+		}
+		return n, cases
+	}
+
+	n, cases := count(files)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, cases)
+
+	tags = []string{"nep5"}
+	n, cases = count(files)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 2, cases)
+
+	tags, skipTags = nil, []string{"smoke"}
+	n, _ = count(files)
+	assert.Equal(t, 1, n)
+
+	skipTags, runPattern = nil, "length"
+	n, cases = count(files)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, cases)
+
+	runPattern = "sub/"
+	n, cases = count(files)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, cases)
+
+	runPattern = "nothing"
+	err := run(files)
+	assert.NoError(t, err)
+
+	runPattern = "("
+	err = run(files)
+	assert.Error(t, err)
+}
+
+func TestFilterIncludedCase(t *testing.T) {
+	files := []string{"../testdata/filter/include.ntf"}
+	for pattern, expected := range map[string][]string{
+		"included": {"included case"},
+		"main":     {"main case"},
+		"case":     {"included case", "main case"},
+		"nothing":  nil,
+	} {
+		filter, err := newFileFilter(nil, nil, pattern)
+		assert.NoError(t, err)
+		results, errs := runFiles(files, filter, 1, true)
+		assert.NoError(t, errs[0])
+		var names []string
+		if results[0] != nil {
+			for _, c := range results[0].Cases {
+				names = append(names, c.Name)
+			}
+		}
+		assert.Equal(t, expected, names)
+	}
+}
+
+func TestDataCmd(t *testing.T) {
+	err := run([]string{"../testdata/data.ntf", "../testdata/data_file.ntf"})
+	assert.NoError(t, err)
+}
+
+func TestCheckFiles(t *testing.T) {
+	var buf bytes.Buffer
+	n := checkFiles([]string{"../testdata/check/good.ntf"}, &buf)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "", buf.String())
+
+	n = checkFiles([]string{"../testdata/check/bad.ntf", "../testdata/none.ntf"}, &buf)
+	assert.Equal(t, 6, n)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "../testdata/check/bad.ntf:4:1: unknown cmd: foo", lines[0])
+	assert.Equal(t, "../testdata/check/bad.ntf:6:10: variable undefine: undefined", lines[1])
+	assert.Equal(t, "../testdata/check/bad.ntf:11:5: case must not be nested in other block", lines[2])
+	assert.Equal(t, "../testdata/check/bad.ntf:18:1: procedure greet redeclared", lines[3])
+	assert.Equal(t, "../testdata/check/bad.ntf:21:18: variable undefine: b", lines[4])
+	assert.True(t, strings.HasPrefix(lines[5], "../testdata/none.ntf: "))
+}
+
+func TestFormatFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "neotest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	data, err := ioutil.ReadFile("../testdata/fmt/messy.ntf")
+	assert.NoError(t, err)
+	file := dir + "/messy.ntf"
+	assert.NoError(t, ioutil.WriteFile(file, data, 0644))
+
+	var buf bytes.Buffer
+	err = formatFiles([]string{file}, false, &buf)
+	assert.NoError(t, err)
+	golden, err := ioutil.ReadFile("../testdata/fmt/messy.golden")
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), buf.String())
+
+	buf.Reset()
+	err = formatFiles([]string{file}, true, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "", buf.String())
+	formatted, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), string(formatted))
+
+	err = formatFiles([]string{"../testdata/check/bad.ntf"}, false, &buf)
+	assert.Error(t, err)
+}
+
+func TestRunRepl(t *testing.T) {
+	lines := []string{"let @a 1", "if true", "echo $(a)", "end", ":vars", ":tx", "tx \"t\"", ":tx", "foo", ":bad", ":quit", "echo 2"}
+	prompt := func(string) (string, error) {
+		line := lines[0]
+		lines = lines[1:]
+		return line, nil
+	}
+	var history []string
+	var buf bytes.Buffer
+	err := runRepl(neotest.NewRepl(), prompt, func(line string) { history = append(history, line) }, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo 2"}, lines)
+	assert.Equal(t, 11, len(history))
+
+	out := buf.String()
+	assert.True(t, strings.Contains(out, "1 \n"))
+	assert.True(t, strings.Contains(out, `"a": 1`))
+	assert.True(t, strings.Contains(out, "no pending tx"))
+	assert.True(t, strings.Contains(out, `"name": "t"`))
+	assert.False(t, strings.Contains(out, `"txid"`))
+	assert.True(t, strings.Contains(out, "unknown cmd: foo"))
+	assert.True(t, strings.Contains(out, "unknown command :bad"))
+}
+
+func TestDebug(t *testing.T) {
+	lines := []string{"b 7", "c", "p $(a)", "bad", "req", "tx", "break x", "n", "q"}
+	prompt := func(string) (string, error) {
+		line := lines[0]
+		lines = lines[1:]
+		return line, nil
+	}
+	var buf bytes.Buffer
+	err := debug("../testdata/debug.ntf", prompt, &buf)
+	assert.Equal(t, neotest.ErrDebugQuit, err)
+	assert.Equal(t, 0, len(lines))
+
+	out := buf.String()
+	assert.True(t, strings.Contains(out, "../testdata/debug.ntf:7\techo \"a is\" $(a)\n"))
+	assert.True(t, strings.Contains(out, "\n2\n"))
+	assert.True(t, strings.Contains(out, "unknown command bad"))
+	assert.True(t, strings.Contains(out, "no pending http request"))
+	assert.True(t, strings.Contains(out, "no pending tx"))
+	assert.True(t, strings.Contains(out, "invalid breakpoint"))
+	assert.True(t, strings.Contains(out, "../testdata/debug.ntf:9\treq"))
+	assert.False(t, strings.Contains(out, "finished"))
+}
+
+func TestDebugPendingTx(t *testing.T) {
+	d, err := neotest.NewDebugger("../testdata/debug.ntf")
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	d.VM.Out = &buf
+	d.SetBreakpoint("", 12)
+	d.Pause = func(cmd neotest.Commander) {
+		if cmd.Line() == 12 {
+			debugCommand(d, cmd, "tx", &buf)
+		}
+		d.Continue()
+	}
+	assert.NoError(t, d.Run())
+
+	out := buf.String()
+	assert.True(t, strings.Contains(out, `"name": "transfer"`))
+	assert.False(t, strings.Contains(out, `"txid"`))
+
+	//hash of pending tx is not computed by showing it
+	d.VM.CurTx.Version = 1
+	expected := neo.NewTx("transfer")
+	expected.Version = 1
+	assert.Equal(t, expected.Hash(), d.VM.CurTx.Hash())
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/pkg/pln"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const debugHelp = `  b, break [file:]<line>   set breakpoint, file is relative to the debugged file
+  clear [file:]<line>      remove breakpoint
+  bl, breakpoints          list breakpoints
+  s, step                  run the next command, step into blocks and procedures
+  n, next                  run to the next command in the same block
+  c, continue              run to the next breakpoint
+  p, print <expr>          evaluate expression, e.g. print $(resp.body)
+  vars                     show variables
+  tx                       show the pending tx
+  req                      show the pending http request
+  l, list                  show source around the paused command
+  q, quit                  stop debugging
+  h, help                  show this help`
+
+func newDebugCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "debug <file>",
+		Short: "Debug a ntf file with breakpoints and stepping",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pln.Verbose = verbose
+			line := liner.NewLiner()
+			err := debug(args[0], line.Prompt, os.Stdout)
+			line.Close()
+			if err != nil && err != neotest.ErrDebugQuit {
+				pln.Error(err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose")
+	return cmd
+}
+
+//debug run file in debugger, the debugging commands are read by prompt when the vm pauses
+func debug(file string, prompt func(string) (string, error), out io.Writer) error {
+	d, err := neotest.NewDebugger(file)
+	if err != nil {
+		return err
+	}
+	d.VM.Out = out
+	d.Pause = func(cmd neotest.Commander) {
+		fmt.Fprintf(out, "%v:%v\t%v\n", d.VM.File, cmd.Line(), cmdText(cmd))
+		for {
+			line, err := prompt("(debug) ")
+			if err != nil {
+				d.Quit()
+				return
+			}
+			if debugCommand(d, cmd, strings.TrimSpace(line), out) {
+				return
+			}
+		}
+	}
+
+	err = d.Run()
+	if err == nil {
+		fmt.Fprintln(out, "finished")
+	}
+	return err
+}
+
+//debugCommand execute a debugging command, it returns true if the vm should resume
+func debugCommand(d *neotest.Debugger, cmd neotest.Commander, text string, out io.Writer) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	arg := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+
+	switch fields[0] {
+	case "s", "step":
+		d.Step()
+		return true
+	case "n", "next":
+		d.Next()
+		return true
+	case "c", "continue":
+		d.Continue()
+		return true
+	case "q", "quit":
+		d.Quit()
+		return true
+	case "b", "break":
+		file, line, err := parseBreakpoint(arg)
+		if err != nil {
+			pln.FError(out, err)
+			return false
+		}
+		d.SetBreakpoint(file, line)
+	case "clear":
+		file, line, err := parseBreakpoint(arg)
+		if err == nil && !d.ClearBreakpoint(file, line) {
+			err = fmt.Errorf("no breakpoint at %v", arg)
+		}
+		pln.FError(out, err)
+	case "bl", "breakpoints":
+		for _, b := range d.Breakpoints() {
+			fmt.Fprintln(out, b)
+		}
+	case "p", "print":
+		v, err := d.Eval(arg)
+		if err != nil {
+			pln.FError(out, err)
+			return false
+		}
+		fmt.Fprintln(out, prettyJSON(v))
+	case "vars":
+		fmt.Fprintln(out, prettyJSON(d.VM.Vars()))
+	case "tx":
+		if d.VM.CurTx == nil {
+			fmt.Fprintln(out, "no pending tx")
+			return false
+		}
+		fmt.Fprintln(out, prettyJSON(d.VM.CurTx.PendingMap()))
+	case "req":
+		if d.VM.CurHttpReq == nil {
+			fmt.Fprintln(out, "no pending http request")
+			return false
+		}
+		fmt.Fprintln(out, prettyJSON(d.VM.CurHttpReq))
+	case "l", "list":
+		listSource(d.VM.File, cmd.Line(), out)
+	case "h", "help":
+		fmt.Fprintln(out, debugHelp)
+	default:
+		pln.FError(out, fmt.Errorf("unknown command %v, type help for help", fields[0]))
+	}
+	return false
+}
+
+//parseBreakpoint parse breakpoint in form of [file:]line
+func parseBreakpoint(s string) (string, int, error) {
+	var file string
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		file, s = s[:i], s[i+1:]
+	}
+	line, err := strconv.Atoi(s)
+	if err != nil || line <= 0 {
+		return "", 0, fmt.Errorf("invalid breakpoint, should be [file:]<line>")
+	}
+	return file, line, nil
+}
+
+//listSource print lines around line of file, the line is marked by '=>'
+func listSource(file string, line int, out io.Writer) {
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		pln.FError(out, err)
+		return
+	}
+	lines := strings.Split(string(bs), "\n")
+	for i := line - 5; i <= line+5; i++ {
+		if i < 1 || i > len(lines) {
+			continue
+		}
+		mark := "  "
+		if i == line {
+			mark = "=>"
+		}
+		fmt.Fprintf(out, "%v %4d  %v\n", mark, i, lines[i-1])
+	}
+}
+
+func cmdText(cmd neotest.Commander) string {
+	if t, ok := cmd.(interface{ Text() string }); ok {
+		return t.Text()
+	}
+	return cmd.Name()
+}
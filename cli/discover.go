@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//discoverFiles expand arguments into ntf files, an argument can be a file, a directory
+//which is searched recursively for *.ntf, or a glob pattern matching them
+func discoverFiles(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, arg := range args {
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %v: %v", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no file matches %v", arg)
+			}
+			paths = matches
+		}
+
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(path)
+				continue
+			}
+
+			var found []string
+			err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() && filepath.Ext(file) == ".ntf" {
+					found = append(found, file)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(found)
+			for _, file := range found {
+				add(file)
+			}
+		}
+	}
+	return files, nil
+}
+
+//fileFilter select files by tags and select files and cases by name
+type fileFilter struct {
+	tags     []string
+	skipTags []string
+	run      *regexp.Regexp
+}
+
+func newFileFilter(tags, skipTags []string, run string) (*fileFilter, error) {
+	f := &fileFilter{tags: trimTags(tags), skipTags: trimTags(skipTags)}
+	if run != "" {
+		re, err := regexp.Compile(run)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run pattern: %v", err)
+		}
+		f.run = re
+	}
+	return f, nil
+}
+
+//matchTags a file is selected if it has one of tags and has none of skip-tags
+func (f *fileFilter) matchTags(tags []string) bool {
+	for _, tag := range f.skipTags {
+		if containsString(tags, tag) {
+			return false
+		}
+	}
+	if len(f.tags) == 0 {
+		return true
+	}
+	for _, tag := range f.tags {
+		if containsString(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+//caseFilter filter of cases in file. All cases run if run pattern is not set or it matches file name,
+//otherwise only the matched cases run. The file is skipped if no case may match, the cases nested in blocks
+//and included files are matched as well
+func (f *fileFilter) caseFilter(file string, commands []neotest.Commander) (filter func(string) bool, skip bool) {
+	if f.run == nil || f.run.MatchString(file) {
+		return nil, false
+	}
+
+	var matched bool
+	neotest.Walk(commands, func(cmd neotest.Commander) {
+		c, ok := cmd.(*neotest.CaseCmd)
+		if !ok {
+			return
+		}
+		//name with variable may match on running
+		if name, ok := c.CaseName(); !ok || f.run.MatchString(name) {
+			matched = true
+		}
+	})
+	if matched {
+		return f.run.MatchString, false
+	}
+	return nil, true
+}
+
+func trimTags(tags []string) []string {
+	var trimmed []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			trimmed = append(trimmed, tag)
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/pkg/pln"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+func newFmtCmd() *cobra.Command {
+	var write bool
+	cmd := &cobra.Command{
+		Use:   "fmt [-w] <files>",
+		Short: "Format ntf files in canonical layout",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			files, err := discoverFiles(args)
+			if err == nil {
+				err = formatFiles(files, write, os.Stdout)
+			}
+			if err != nil {
+				pln.Error(err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVarP(&write, "write", "w", false, "Write result to the source file instead of stdout")
+	return cmd
+}
+
+//formatFiles format files, the result is written to the files which are changed if write is set, otherwise to w
+func formatFiles(files []string, write bool, w io.Writer) error {
+	for _, file := range files {
+		src, err := neotest.NewSource(file)
+		if err != nil {
+			return err
+		}
+		formatted, err := src.Format()
+		if err != nil {
+			return fmt.Errorf("file: %v, %v", file, err)
+		}
+		if !write {
+			_, err = w.Write(formatted)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		origin, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(origin, formatted) {
+			continue
+		}
+		err = ioutil.WriteFile(file, formatted, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
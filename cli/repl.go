@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/pkg/pln"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const replHelp = `Input commands of ntf, a block is executed after its 'end'.
+  :vars    show variables
+  :tx      show the pending tx
+  :help    show this help
+  :quit    exit, same as Ctrl-D`
+
+func newReplCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repl",
+		Short: "Interactive shell executing commands immediately",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			pln.Verbose = verbose
+			line := liner.NewLiner()
+			defer line.Close()
+			line.SetCtrlCAborts(true)
+
+			r := neotest.NewRepl()
+			line.SetCompleter(r.Complete)
+
+			history := filepath.Join(os.Getenv("HOME"), ".neotest_history")
+			if f, err := os.Open(history); err == nil {
+				line.ReadHistory(f)
+				f.Close()
+			}
+			defer func() {
+				if f, err := os.Create(history); err == nil {
+					line.WriteHistory(f)
+					f.Close()
+				}
+			}()
+
+			fmt.Println("neotest repl, type :help for help")
+			err := runRepl(r, line.Prompt, line.AppendHistory, os.Stdout)
+			if err != nil {
+				pln.Error(err)
+			}
+		},
+	}
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose")
+	return cmd
+}
+
+//runRepl read input by prompt until EOF or ':quit', the lines of a block are joined and executed together
+func runRepl(r *neotest.Repl, prompt func(string) (string, error), appendHistory func(string), out io.Writer) error {
+	r.VM.Out = out
+	var input string
+	for {
+		p := "> "
+		if input != "" {
+			p = "... "
+		}
+		line, err := prompt(p)
+		if err == liner.ErrPromptAborted {
+			input = ""
+			continue
+		}
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			appendHistory(line)
+		}
+
+		if input == "" {
+			text := strings.TrimSpace(line)
+			if text == "" {
+				continue
+			}
+			if strings.HasPrefix(text, ":") {
+				if text == ":quit" || text == ":q" {
+					return nil
+				}
+				replCommand(r, text, out)
+				continue
+			}
+		}
+
+		input += line + "\n"
+		more, err := r.Eval(input)
+		if more {
+			continue
+		}
+		input = ""
+		pln.FError(out, err)
+	}
+}
+
+//replCommand execute command of repl itself which starts with ':'
+func replCommand(r *neotest.Repl, text string, out io.Writer) {
+	switch text {
+	case ":vars":
+		fmt.Fprintln(out, prettyJSON(r.VM.Vars()))
+	case ":tx":
+		if r.VM.CurTx == nil {
+			fmt.Fprintln(out, "no pending tx")
+			return
+		}
+		fmt.Fprintln(out, prettyJSON(r.VM.CurTx.PendingMap()))
+	case ":help":
+		fmt.Fprintln(out, replHelp)
+	default:
+		pln.FError(out, fmt.Errorf("unknown command %v, type :help for help", text))
+	}
+}
@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var reportFormats = []string{"junit", "json", "tap"}
+
+//FileResult result of running a ntf file
+type FileResult struct {
+	File       string
+	Duration   time.Duration
+	Assertions []*neotest.Assertion
+	Cases      []*neotest.CaseResult
+	TxHashes   []string
+	Steps      []*neotest.Step
+	Err        error
+}
+
+//Units test units of file, they are the cases and the commands out of cases.
+//The commands out of cases is a unit named by file, it's omitted if the file has cases
+//and there is no assertion, tx or error out of cases
+func (r *FileResult) Units() []*neotest.CaseResult {
+	inCase := make(map[*neotest.Assertion]bool)
+	txInCase := make(map[string]bool)
+	stepInCase := make(map[*neotest.Step]bool)
+	for _, c := range r.Cases {
+		for _, s := range c.Steps {
+			stepInCase[s] = true
+		}
+		for _, a := range c.Assertions {
+			inCase[a] = true
+		}
+		for _, h := range c.TxHashes {
+			txInCase[h] = true
+		}
+	}
+
+	top := &neotest.CaseResult{Name: r.File, File: r.File, Err: r.Err}
+	for _, a := range r.Assertions {
+		if !inCase[a] {
+			top.Assertions = append(top.Assertions, a)
+		}
+	}
+	for _, h := range r.TxHashes {
+		if !txInCase[h] {
+			top.TxHashes = append(top.TxHashes, h)
+		}
+	}
+
+	for _, s := range r.Steps {
+		if !stepInCase[s] {
+			top.Steps = append(top.Steps, s)
+		}
+	}
+
+	if len(r.Cases) == 0 {
+		top.Duration = r.Duration
+		return []*neotest.CaseResult{top}
+	}
+	if len(top.Assertions) == 0 && len(top.TxHashes) == 0 && top.Err == nil {
+		return r.Cases
+	}
+	return append([]*neotest.CaseResult{top}, r.Cases...)
+}
+
+//Pass whether all units of file pass
+func (r *FileResult) Pass() bool {
+	for _, u := range r.Units() {
+		if !u.Pass() {
+			return false
+		}
+	}
+	return true
+}
+
+//writeReport write results in format of junit, json or tap
+func writeReport(format, out string, results []*FileResult) error {
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "junit":
+		return writeJUnit(w, results)
+	case "json":
+		return writeJSON(w, results)
+	case "tap":
+		return writeTAP(w, results)
+	}
+	return fmt.Errorf("unknown report format %v, should be one of %v", format, strings.Join(reportFormats, ", "))
+}
+
+//failureLines failed assertions and error of unit
+func failureLines(u *neotest.CaseResult) []string {
+	var lines []string
+	for _, a := range u.Assertions {
+		if !a.Pass {
+			lines = append(lines, a.String())
+		}
+	}
+	//error of failed assertion is already in lines unless the assertion is not recorded in unit
+	if _, ok := unwrapAssertError(u.Err); u.Err != nil && (!ok || len(lines) == 0) {
+		lines = append(lines, u.Err.Error())
+	}
+	return lines
+}
+
+func unwrapAssertError(err error) (*neotest.AssertError, bool) {
+	if e, ok := err.(*neotest.ExecError); ok {
+		err = e.Err
+	}
+	e, ok := err.(*neotest.AssertError)
+	return e, ok
+}
+
+type junitSuites struct {
+	XMLName xml.Name      `xml:"testsuites"`
+	Suites  []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Cases    []*junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, results []*FileResult) error {
+	suites := &junitSuites{}
+	for _, r := range results {
+		suite := &junitSuite{Name: r.File, Time: seconds(r.Duration)}
+		for _, u := range r.Units() {
+			tc := &junitCase{Name: u.Name, ClassName: r.File, Time: seconds(u.Duration)}
+			if !u.Pass() {
+				lines := failureLines(u)
+				failure := &junitFailure{Text: strings.Join(lines, "\n")}
+				if len(lines) > 0 {
+					failure.Message = lines[0]
+				}
+				//an error which is not assertion stops the unit
+				if _, ok := unwrapAssertError(u.Err); u.Err != nil && !ok {
+					tc.Error = failure
+					suite.Errors++
+				} else {
+					tc.Failure = failure
+					suite.Failures++
+				}
+			}
+			if len(u.TxHashes) > 0 {
+				tc.SystemOut = "tx: " + strings.Join(u.TxHashes, "\ntx: ")
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(suites)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+type jsonReport struct {
+	Pass  bool        `json:"pass"`
+	Files []*jsonFile `json:"files"`
+}
+
+type jsonFile struct {
+	File     string      `json:"file"`
+	Pass     bool        `json:"pass"`
+	Duration float64     `json:"duration"`
+	TxHashes []string    `json:"tx_hashes"`
+	Error    string      `json:"error,omitempty"`
+	Cases    []*jsonCase `json:"cases"`
+}
+
+type jsonCase struct {
+	Name       string               `json:"name"`
+	Line       int                  `json:"line"`
+	Pass       bool                 `json:"pass"`
+	Duration   float64              `json:"duration"`
+	TxHashes   []string             `json:"tx_hashes"`
+	Error      string               `json:"error,omitempty"`
+	Assertions []*neotest.Assertion `json:"assertions"`
+}
+
+func writeJSON(w io.Writer, results []*FileResult) error {
+	report := &jsonReport{Pass: true, Files: []*jsonFile{}}
+	for _, r := range results {
+		f := &jsonFile{
+			File:     r.File,
+			Pass:     r.Pass(),
+			Duration: r.Duration.Seconds(),
+			TxHashes: nonNil(r.TxHashes),
+			Error:    errString(r.Err),
+		}
+		for _, u := range r.Units() {
+			f.Cases = append(f.Cases, &jsonCase{
+				Name:       u.Name,
+				Line:       u.Line,
+				Pass:       u.Pass(),
+				Duration:   u.Duration.Seconds(),
+				TxHashes:   nonNil(u.TxHashes),
+				Error:      errString(u.Err),
+				Assertions: u.Assertions,
+			})
+		}
+		report.Pass = report.Pass && f.Pass
+		report.Files = append(report.Files, f)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func writeTAP(w io.Writer, results []*FileResult) error {
+	var units []*neotest.CaseResult
+	for _, r := range results {
+		units = append(units, r.Units()...)
+	}
+
+	fmt.Fprintf(w, "TAP version 13\n1..%v\n", len(units))
+	for i, u := range units {
+		status := "ok"
+		if !u.Pass() {
+			status = "not ok"
+		}
+		name := u.Name
+		if name != u.File {
+			name = fmt.Sprintf("%v: %v", u.File, u.Name)
+		}
+		fmt.Fprintf(w, "%v %v - %v\n", status, i+1, name)
+
+		//yaml diagnostic block
+		fmt.Fprintf(w, "  ---\n  duration_ms: %.3f\n", float64(u.Duration)/float64(time.Millisecond))
+		if lines := failureLines(u); len(lines) > 0 {
+			fmt.Fprintf(w, "  failures:\n")
+			for _, line := range lines {
+				fmt.Fprintf(w, "    - %q\n", line)
+			}
+		}
+		if len(u.TxHashes) > 0 {
+			fmt.Fprintf(w, "  tx_hashes:\n")
+			for _, h := range u.TxHashes {
+				fmt.Fprintf(w, "    - %v\n", h)
+			}
+		}
+		_, err := fmt.Fprintf(w, "  ...\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
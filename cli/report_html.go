@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+)
+
+var htmlFuncs = template.FuncMap{
+	"duration": func(d time.Duration) string {
+		return fmt.Sprintf("%.3fms", float64(d)/float64(time.Millisecond))
+	},
+	"indent": func(depth int) template.CSS {
+		return template.CSS(fmt.Sprintf("padding-left: %vem", float64(depth)*2+0.5))
+	},
+	"pretty": prettyJSON,
+	"failed": func(s *neotest.Step) bool {
+		if s.Err != nil {
+			return true
+		}
+		for _, a := range s.Assertions {
+			if !a.Pass {
+				return true
+			}
+		}
+		return false
+	},
+	"errString": errString,
+	"failures":  failureLines,
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>neotest report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.3em; border-bottom: 1px solid #e1e4e8; padding-bottom: .3em; margin-top: 2em; }
+h3 { font-size: 1.1em; }
+.pass { color: #22863a; }
+.fail { color: #cb2431; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1em; }
+td, th { border: 1px solid #e1e4e8; padding: 4px 8px; text-align: left; vertical-align: top; font-size: .9em; }
+th { background: #f6f8fa; }
+tr.failed td { background: #ffeef0; }
+code, pre { font-family: SFMono-Regular, Consolas, Menlo, monospace; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; white-space: pre-wrap; word-break: break-all; margin: 4px 0; }
+ul.failures { background: #ffeef0; padding: 8px 8px 8px 2em; }
+details summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>neotest report</h1>
+<p>{{len .Files}} files, generated at {{.Time}}</p>
+{{range .Files}}
+<h2 class="{{if .Pass}}pass{{else}}fail{{end}}">{{.File}} <small>({{duration .Duration}})</small></h2>
+{{range .Units}}
+<h3 class="{{if .Pass}}pass{{else}}fail{{end}}">{{if .Pass}}&#10004;{{else}}&#10008;{{end}} {{.Name}} <small>({{duration .Duration}})</small></h3>
+{{with failures .}}<ul class="failures">{{range .}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+{{if .Steps}}
+<table>
+<tr><th>file</th><th>line</th><th>command</th><th>time</th><th>detail</th></tr>
+{{range .Steps}}
+<tr{{if failed .}} class="failed"{{end}}>
+<td>{{.File}}</td>
+<td>{{.Line}}</td>
+<td style="{{indent .Depth}}"><code>{{.Text}}</code></td>
+<td>{{duration .Duration}}</td>
+<td>
+{{range .Assertions}}<div class="{{if .Pass}}pass{{else}}fail{{end}}">{{.Cmd}} {{if .Pass}}pass{{else}}fail: {{.Message}}{{end}}</div>{{end}}
+{{with .Err}}<div class="fail">{{errString .}}</div>{{end}}
+{{with .Tx}}<details><summary>tx</summary><pre>{{pretty .}}</pre></details>{{end}}
+{{with .TxHex}}<details><summary>raw hex</summary><pre>{{.}}</pre></details>{{end}}
+{{range .Exchanges}}<details><summary>{{.Kind}} {{.Method}} {{.URL}}{{if .Code}} {{.Code}}{{end}}</summary>
+<b>request</b><pre>{{pretty .Request}}</pre>
+{{if .Err}}<b>error</b><pre class="fail">{{.Err}}</pre>{{else}}<b>response</b><pre>{{pretty .Response}}</pre>{{end}}
+</details>{{end}}
+</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+//writeHTMLReport write a single static html file of results
+func writeHTMLReport(out string, results []*FileResult) error {
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return htmlTemplate.Execute(f, map[string]interface{}{
+		"Time":  time.Now().Format("2006-01-02 15:04:05"),
+		"Files": results,
+	})
+}
+
+//prettyJSON indent json value or json string, other string is returned as it is
+func prettyJSON(v interface{}) string {
+	if s, ok := v.(string); ok {
+		var data interface{}
+		if json.Unmarshal([]byte(s), &data) != nil {
+			return s
+		}
+		v = data
+	}
+	bs, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSpace(string(bs))
+}
@@ -19,9 +19,33 @@ type Commander interface {
 	RunExprIndexOf(index int, vm *VM) (interface{}, error)
 }
 
+//Keyworder command which accepts bare words as arguments, e.g. 'in' of 'for'
+type Keyworder interface {
+	IsKeyword(index int, word string) bool
+}
+
+//Linker command which resolves its arguments from source after checking, e.g. 'call'
+type Linker interface {
+	Link(src *Source) error
+}
+
+//Prefixer command which prefixes another command, e.g. 'expect' of 'expect equal 1 1'
+type Prefixer interface {
+	SetCmd(cmd Commander) error
+}
+
+//texter command which keeps its source text and column
+type texter interface {
+	Text() string
+	Column() int
+	setText(text string, column int)
+}
+
 type Cmd struct {
 	name     string
+	text     string
 	line     int
+	column   int
 	cmd      *cobra.Command
 	exprList []ExprNode
 }
@@ -46,6 +70,21 @@ func (c *Cmd) Name() string {
 	return c.name
 }
 
+//Text source text of command
+func (c *Cmd) Text() string {
+	return c.text
+}
+
+//Column column of command in line, it's 1-based
+func (c *Cmd) Column() int {
+	return c.column
+}
+
+func (c *Cmd) setText(text string, column int) {
+	c.text = text
+	c.column = column
+}
+
 func (c *Cmd) ExprList() []ExprNode {
 	return c.exprList
 }
@@ -62,7 +101,23 @@ func (c *Cmd) RunExprIndexOf(index int, vm *VM) (interface{}, error) {
 	return c.exprList[index].Run(vm)
 }
 
+//CheckExprNumAndType check the number of expressions is one of num and the types of them, it's used by
+//CheckExpr of commands to check arguments on source-parsing stage. Any accepts value of any type
+func CheckExprNumAndType(exprList []ExprNode, num []int, types ...ExprType) error {
+	return checkExprNumAndType(exprList, num, types...)
+}
+
 func checkExprNumAndType(exprList []ExprNode, num []int, types ...ExprType) error {
+	return checkExprs(exprList, num, false, types...)
+}
+
+//checkCondExpr check condition of block and assertion command. Internal variables are accepted as any type,
+//since their type is only known at runtime, e.g. 'while $(resp.body.ok)'
+func checkCondExpr(exprList []ExprNode, num []int, types ...ExprType) error {
+	return checkExprs(exprList, num, true, types...)
+}
+
+func checkExprs(exprList []ExprNode, num []int, internal bool, types ...ExprType) error {
 	var s []string
 	for _, n := range num {
 		s = append(s, strconv.Itoa(n))
@@ -71,12 +126,20 @@ func checkExprNumAndType(exprList []ExprNode, num []int, types ...ExprType) erro
 				return fmt.Errorf("length of types must >= num")
 			}
 			for i := range exprList {
-				switch exprList[i].Type() {
-				case SubCommand:
+				if types[i] == Any {
+					if exprList[i].Type() == Identity || exprList[i].Type() == Keyword {
+						return fmt.Errorf("index of expr at %v must be value", i)
+					}
+					continue
+				}
+				switch {
+				case exprList[i].Type() == SubCommand:
 					expect, actual := types[i].String(), exprList[i].(Resultant).ResultType()
 					if expect != actual {
 						return fmt.Errorf("index of expr at %v must be %v", i, types[i].String())
 					}
+				case exprList[i].Type() == InternalVar && (internal || !usesInternalVar(exprList[i])):
+					//untyped variable, e.g. argument of procedure, is checked at runtime
 				default:
 					if exprList[i].Type() != types[i] {
 						return fmt.Errorf("index of expr at %v must be %v", i, types[i].String())
@@ -90,6 +153,20 @@ func checkExprNumAndType(exprList []ExprNode, num []int, types ...ExprType) erro
 	return fmt.Errorf("num of expr must be %v, but it is %v", strings.Join(s, " or"), len(exprList))
 }
 
+//usesInternalVar whether expr refers to internal variables such as resp and tx
+func usesInternalVar(expr ExprNode) bool {
+	v, ok := expr.(Variate)
+	if !ok {
+		return true
+	}
+	for _, ID := range v.Variables() {
+		if _, ok := internalVarMap[varRoot(ID)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func toString(v interface{}, err error) (string, error) {
 	if err != nil {
 		return "", err
@@ -111,3 +188,14 @@ func toFloat64(v interface{}, err error) (float64, error) {
 	}
 	return f, nil
 }
+
+func toBool(v interface{}, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expr is not bool type")
+	}
+	return b, nil
+}
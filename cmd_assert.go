@@ -0,0 +1,397 @@
+package neotest
+
+import (
+	"fmt"
+	"github.com/hzxiao/goutil"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+//Asserter command which makes assertion, it can be prefixed by 'assert' or 'expect'
+type Asserter interface {
+	Commander
+	IsAsserter()
+}
+
+var _ Asserter = new(EqualCmd)
+var _ Asserter = new(RetCmd)
+var _ Asserter = new(NotEqualCmd)
+var _ Asserter = new(ContainsCmd)
+var _ Asserter = new(MatchCmd)
+var _ Asserter = new(CompareCmd)
+var _ Asserter = new(ExistsCmd)
+var _ Asserter = new(LenEqualCmd)
+var _ Asserter = new(TypeIsCmd)
+var _ Prefixer = new(PrefixAssertCmd)
+
+//PrefixAssertCmd 'assert' and 'expect' command. A failed 'assert' stops running,
+//but a failed 'expect' is only recorded and the following commands keep running
+type PrefixAssertCmd struct {
+	*Cmd
+	assertion Commander
+	soft      bool
+}
+
+func NewAssertCmd(line int) *PrefixAssertCmd {
+	return &PrefixAssertCmd{
+		Cmd: NewCmd("assert", "assert <assertion-cmd> <object1> ...", line),
+	}
+}
+
+func NewExpectCmd(line int) *PrefixAssertCmd {
+	return &PrefixAssertCmd{
+		Cmd:  NewCmd("expect", "expect <assertion-cmd> <object1> ...", line),
+		soft: true,
+	}
+}
+
+func (c *PrefixAssertCmd) SetCmd(cmd Commander) error {
+	if _, ok := cmd.(Asserter); !ok {
+		return fmt.Errorf("%v is not an assertion command", cmd.Name())
+	}
+	c.assertion = cmd
+	return nil
+}
+
+func (c *PrefixAssertCmd) Exec(vm *VM) error {
+	err := c.assertion.Exec(vm)
+	if _, ok := err.(*AssertError); ok && c.soft {
+		return nil
+	}
+	return err
+}
+
+func (eq *EqualCmd) IsAsserter() {}
+
+func (c *RetCmd) IsAsserter() {}
+
+func (c *NotEqualCmd) IsAsserter() {}
+
+func (c *ContainsCmd) IsAsserter() {}
+
+func (c *MatchCmd) IsAsserter() {}
+
+func (c *CompareCmd) IsAsserter() {}
+
+func (c *ExistsCmd) IsAsserter() {}
+
+func (c *LenEqualCmd) IsAsserter() {}
+
+func (c *TypeIsCmd) IsAsserter() {}
+
+//NotEqualCmd 'not-equal' command
+type NotEqualCmd struct {
+	*Cmd
+}
+
+func NewNotEqualCmd(line int) *NotEqualCmd {
+	return &NotEqualCmd{
+		Cmd: NewCmd("not-equal", "not-equal <object1> <object2>", line),
+	}
+}
+
+func (c *NotEqualCmd) Exec(vm *VM) error {
+	actual, expected, err := runAssertArgs(c.Cmd, vm)
+	if err != nil {
+		return err
+	}
+
+	ok := !valueEqual(actual, expected)
+	return vm.Assert(c, ok, actual, expected, fmt.Sprintf("%v == %v", formatValue(actual), formatValue(expected)))
+}
+
+func (c *NotEqualCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{2}, Any, Any)
+}
+
+//ContainsCmd 'contains' command, check sub string of string, element of array or key of map
+type ContainsCmd struct {
+	*Cmd
+}
+
+func NewContainsCmd(line int) *ContainsCmd {
+	return &ContainsCmd{
+		Cmd: NewCmd("contains", "contains <string|array|map> <object>", line),
+	}
+}
+
+func (c *ContainsCmd) Exec(vm *VM) error {
+	container, item, err := runAssertArgs(c.Cmd, vm)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	switch container := container.(type) {
+	case string:
+		s, isStr := item.(string)
+		if !isStr {
+			return fmt.Errorf("cannot check %v (type %T) in string", item, item)
+		}
+		ok = strings.Contains(container, s)
+	case goutil.Map:
+		_, ok = container[goutil.String(item)]
+	case map[string]interface{}:
+		_, ok = container[goutil.String(item)]
+	default:
+		rv := reflect.ValueOf(container)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return fmt.Errorf("cannot check contains in %v (type %T)", container, container)
+		}
+		for i := 0; i < rv.Len() && !ok; i++ {
+			ok = valueEqual(rv.Index(i).Interface(), item)
+		}
+	}
+
+	return vm.Assert(c, ok, container, item, fmt.Sprintf("%v does not contain %v", formatValue(container), formatValue(item)))
+}
+
+func (c *ContainsCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{2}, Any, Any)
+}
+
+//MatchCmd 'match' command, check string by regular expression
+type MatchCmd struct {
+	*Cmd
+}
+
+func NewMatchCmd(line int) *MatchCmd {
+	return &MatchCmd{
+		Cmd: NewCmd("match", "match <string> <regex>", line),
+	}
+}
+
+func (c *MatchCmd) Exec(vm *VM) error {
+	s, err := toString(c.RunExprIndexOf(0, vm))
+	if err != nil {
+		return err
+	}
+	pattern, err := toString(c.RunExprIndexOf(1, vm))
+	if err != nil {
+		return err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+
+	return vm.Assert(c, re.MatchString(s), s, pattern, fmt.Sprintf("%v does not match %v", formatValue(s), pattern))
+}
+
+//CheckExpr string to match is usually in response, so internal variable is accepted
+func (c *MatchCmd) CheckExpr(varType map[string]string) error {
+	err := checkExprNumAndType(c.exprList, []int{2}, Any, String)
+	if err == nil {
+		err = checkCondExpr(c.exprList[:1], []int{1}, String)
+	}
+	if err != nil {
+		return err
+	}
+
+	//check regex on source-parsing stage if it's constant
+	if expr, ok := c.exprList[1].(*stringExpr); ok && len(expr.Variables()) == 0 {
+		_, err = regexp.Compile(expr.val)
+	}
+	return err
+}
+
+var compareOperators = map[string]string{
+	"gt": ">",
+	"lt": "<",
+	"ge": ">=",
+	"le": "<=",
+}
+
+//CompareCmd 'gt', 'lt', 'ge' and 'le' command, compare two numbers
+type CompareCmd struct {
+	*Cmd
+	op string
+}
+
+func NewCompareCmd(name string, line int) *CompareCmd {
+	return &CompareCmd{
+		Cmd: NewCmd(name, name+" <number1> <number2>", line),
+		op:  compareOperators[name],
+	}
+}
+
+func (c *CompareCmd) Exec(vm *VM) error {
+	actual, expected, err := runAssertArgs(c.Cmd, vm)
+	if err != nil {
+		return err
+	}
+	x, xNum := toNumber(actual)
+	y, yNum := toNumber(expected)
+	if !xNum || !yNum {
+		return fmt.Errorf("expr is not number type")
+	}
+
+	ok := compare(c.op, x-y)
+	return vm.Assert(c, ok, actual, expected, fmt.Sprintf("%v %v %v is false", formatValue(actual), c.op, formatValue(expected)))
+}
+
+//CheckExpr numbers to compare are usually in response or tx, so internal variables are accepted
+func (c *CompareCmd) CheckExpr(varType map[string]string) error {
+	return checkCondExpr(c.exprList, []int{2}, Float, Float)
+}
+
+//ExistsCmd 'exists' and 'not-exists' command, check whether variable or its path exists
+type ExistsCmd struct {
+	*Cmd
+	negate bool
+}
+
+func NewExistsCmd(line int) *ExistsCmd {
+	return &ExistsCmd{
+		Cmd: NewCmd("exists", "exists $(var)", line),
+	}
+}
+
+func NewNotExistsCmd(line int) *ExistsCmd {
+	return &ExistsCmd{
+		Cmd:    NewCmd("not-exists", "not-exists $(var)", line),
+		negate: true,
+	}
+}
+
+func (c *ExistsCmd) Exec(vm *VM) error {
+	ID, _ := c.exprList[0].(varIdentifier).varID()
+	v, exist := vm.Var(ID)
+	if c.negate {
+		return vm.Assert(c, !exist, v, nil, fmt.Sprintf("%v exists", ID))
+	}
+	return vm.Assert(c, exist, v, nil, fmt.Sprintf("%v does not exist", ID))
+}
+
+func (c *ExistsCmd) CheckExpr(varType map[string]string) error {
+	err := checkExprNumAndType(c.exprList, []int{1}, Any)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := c.exprList[0].(varIdentifier); ok {
+		if _, yes := v.varID(); yes {
+			return nil
+		}
+	}
+	return fmt.Errorf("argument must be a variable")
+}
+
+//LenEqualCmd 'len-equal' command, check length of array, map or string
+type LenEqualCmd struct {
+	*Cmd
+}
+
+func NewLenEqualCmd(line int) *LenEqualCmd {
+	return &LenEqualCmd{
+		Cmd: NewCmd("len-equal", "len-equal <array|map|string> <number>", line),
+	}
+}
+
+func (c *LenEqualCmd) Exec(vm *VM) error {
+	v, err := c.RunExprIndexOf(0, vm)
+	if err != nil {
+		return err
+	}
+	expected, err := toFloat64(c.RunExprIndexOf(1, vm))
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+	default:
+		return fmt.Errorf("cannot get length of %v (type %T)", v, v)
+	}
+
+	actual := rv.Len()
+	return vm.Assert(c, float64(actual) == expected, actual, expected, fmt.Sprintf("length %v != %v", actual, expected))
+}
+
+func (c *LenEqualCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{2}, Any, Float)
+}
+
+//TypeIsCmd 'type-is' command, check type of value
+type TypeIsCmd struct {
+	*Cmd
+}
+
+var valueTypes = []string{"null", "bool", "number", "string", "array", "map"}
+
+func NewTypeIsCmd(line int) *TypeIsCmd {
+	return &TypeIsCmd{
+		Cmd: NewCmd("type-is", "type-is <object> null|bool|number|string|array|map", line),
+	}
+}
+
+func (c *TypeIsCmd) Exec(vm *VM) error {
+	v, err := c.RunExprIndexOf(0, vm)
+	if err != nil {
+		return err
+	}
+	expected, err := toString(c.RunExprIndexOf(1, vm))
+	if err != nil {
+		return err
+	}
+
+	actual := typeOfValue(v)
+	return vm.Assert(c, actual == expected, actual, expected, fmt.Sprintf("type %v != %v", actual, expected))
+}
+
+func (c *TypeIsCmd) CheckExpr(varType map[string]string) error {
+	err := checkExprNumAndType(c.exprList, []int{2}, Any, String)
+	if err != nil {
+		return err
+	}
+
+	if expr, ok := c.exprList[1].(*stringExpr); ok && len(expr.Variables()) == 0 {
+		if !containsString(valueTypes, expr.val) {
+			return fmt.Errorf("unknown type %v, should be one of %v", expr.val, strings.Join(valueTypes, ", "))
+		}
+	}
+	return nil
+}
+
+//typeOfValue type name of value in ntf
+func typeOfValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+//varIdentifier expression which may be a variable
+type varIdentifier interface {
+	varID() (string, bool)
+}
+
+//runAssertArgs run the two arguments of assertion command, the first is actual and the second is expected
+func runAssertArgs(c *Cmd, vm *VM) (actual, expected interface{}, err error) {
+	if len(c.exprList) != 2 {
+		return nil, nil, fmt.Errorf("num of expr must be 2, but it is %v", len(c.exprList))
+	}
+
+	actual, err = c.RunExprIndexOf(0, vm)
+	if err != nil {
+		return
+	}
+	expected, err = c.RunExprIndexOf(1, vm)
+	return
+}
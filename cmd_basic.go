@@ -3,9 +3,7 @@ package neotest
 import (
 	"fmt"
 	"github.com/hzxiao/goutil"
-	"github.com/k0kubun/pp"
 	"github.com/spf13/cobra"
-	"reflect"
 )
 
 //EchoCmd 'echo' command
@@ -22,9 +20,9 @@ func NewEchoCmd(line int) *EchoCmd {
 func (echo *EchoCmd) Exec(vm *VM) error {
 	echo.cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		for _, arg := range args {
-			fmt.Printf("%v ", arg)
+			fmt.Fprintf(vm.Out, "%v ", arg)
 		}
-		fmt.Println()
+		fmt.Fprintln(vm.Out)
 		return nil
 	}
 
@@ -102,6 +100,10 @@ func (let *LetCmd) CheckExpr(varType map[string]string) error {
 		} else {
 			vType = "string"
 		}
+	case Array:
+		vType = "array"
+	case Map:
+		vType = "map"
 	case SubCommand:
 		vType = second.(Resultant).ResultType()
 	case InternalVar:
@@ -136,30 +138,15 @@ func NewEqualCmd(line int) *EqualCmd {
 }
 
 func (eq *EqualCmd) Exec(vm *VM) error {
-	if len(eq.exprList) != 2 {
-		return fmt.Errorf("num of expr must be 2, but it is %v", len(eq.exprList))
-	}
-
-	first, err := eq.exprList[0].Run(vm)
-	if err != nil {
-		return err
-	}
-	second, err := eq.exprList[1].Run(vm)
+	first, second, err := runAssertArgs(eq.Cmd, vm)
 	if err != nil {
 		return err
 	}
 
-	ok := reflect.DeepEqual(first, second)
-	if !ok {
-		pp.Printf("line: %v: %v != %v\n", eq.line, first, second)
-	}
-
-	return nil
+	ok := valueEqual(first, second)
+	return vm.Assert(eq, ok, first, second, fmt.Sprintf("%v != %v", formatValue(first), formatValue(second)))
 }
 
 func (eq *EqualCmd) CheckExpr(varType map[string]string) error {
-	if len(eq.exprList) != 2 {
-		return fmt.Errorf("num of expr must be 2, but it is %v", len(eq.exprList))
-	}
-	return nil
+	return checkExprNumAndType(eq.exprList, []int{2}, Any, Any)
 }
\ No newline at end of file
@@ -0,0 +1,370 @@
+package neotest
+
+import (
+	"fmt"
+	"reflect"
+)
+
+//Block command which contains nested commands, the body is parsed by ParseBlock
+type Block interface {
+	Commander
+	ParseBlock(src *Source) error
+}
+
+//Delimiter command which closes or separates the body of a block, e.g. 'end'
+type Delimiter interface {
+	Commander
+	delimit()
+}
+
+var _ Block = new(IfCmd)
+var _ Block = new(RepeatCmd)
+var _ Block = new(ForCmd)
+var _ Block = new(WhileCmd)
+var _ Keyworder = new(ForCmd)
+var _ Delimiter = new(ElifCmd)
+var _ Delimiter = new(ElseCmd)
+var _ Delimiter = new(EndCmd)
+
+type branch struct {
+	cond     ExprNode
+	commands []Commander
+}
+
+//IfCmd 'if' command
+type IfCmd struct {
+	*Cmd
+	branches []*branch
+}
+
+func NewIfCmd(line int) *IfCmd {
+	return &IfCmd{
+		Cmd: NewCmd("if", "if <bool> ... [elif <bool> ...] [else ...] end", line),
+	}
+}
+
+func (c *IfCmd) ParseBlock(src *Source) error {
+	b := &branch{cond: c.exprList[0]}
+	for {
+		cmds, delim, err := src.parseBlock()
+		if err != nil {
+			return err
+		}
+		b.commands = cmds
+		c.branches = append(c.branches, b)
+
+		switch delim.(type) {
+		case nil:
+			return blockError(c, &notClosedError{c.name})
+		case *EndCmd:
+			return nil
+		}
+
+		if b.cond == nil {
+			err = src.fail(blockError(delim, fmt.Errorf("unexpected %v after else", delim.Name())))
+			if err != nil {
+				return err
+			}
+		}
+		switch delim := delim.(type) {
+		case *ElifCmd:
+			b = &branch{cond: delim.exprList[0]}
+		case *ElseCmd:
+			b = &branch{}
+		}
+	}
+}
+
+func (c *IfCmd) Exec(vm *VM) error {
+	for _, b := range c.branches {
+		if b.cond != nil {
+			ok, err := toBool(b.cond.Run(vm))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+		return vm.execCommands(b.commands)
+	}
+	return nil
+}
+
+func (c *IfCmd) CheckExpr(varType map[string]string) error {
+	return checkCondExpr(c.exprList, []int{1}, Bool)
+}
+
+//ElifCmd 'elif' command, which must be in 'if' block
+type ElifCmd struct {
+	*Cmd
+}
+
+func NewElifCmd(line int) *ElifCmd {
+	return &ElifCmd{
+		Cmd: NewCmd("elif", "elif <bool>", line),
+	}
+}
+
+func (*ElifCmd) delimit() {}
+
+func (c *ElifCmd) Exec(vm *VM) error {
+	return fmt.Errorf("unexpected %v", c.name)
+}
+
+func (c *ElifCmd) CheckExpr(varType map[string]string) error {
+	return checkCondExpr(c.exprList, []int{1}, Bool)
+}
+
+//ElseCmd 'else' command, which must be in 'if' block
+type ElseCmd struct {
+	*Cmd
+}
+
+func NewElseCmd(line int) *ElseCmd {
+	return &ElseCmd{
+		Cmd: NewCmd("else", "else", line),
+	}
+}
+
+func (*ElseCmd) delimit() {}
+
+func (c *ElseCmd) Exec(vm *VM) error {
+	return fmt.Errorf("unexpected %v", c.name)
+}
+
+func (c *ElseCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{0})
+}
+
+//EndCmd 'end' command, close a block
+type EndCmd struct {
+	*Cmd
+}
+
+func NewEndCmd(line int) *EndCmd {
+	return &EndCmd{
+		Cmd: NewCmd("end", "end", line),
+	}
+}
+
+func (*EndCmd) delimit() {}
+
+func (c *EndCmd) Exec(vm *VM) error {
+	return fmt.Errorf("unexpected %v", c.name)
+}
+
+func (c *EndCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{0})
+}
+
+//RepeatCmd 'repeat' command, run the body n times
+type RepeatCmd struct {
+	*Cmd
+	commands []Commander
+}
+
+func NewRepeatCmd(line int) *RepeatCmd {
+	return &RepeatCmd{
+		Cmd: NewCmd("repeat", "repeat <number> ... end", line),
+	}
+}
+
+func (c *RepeatCmd) ParseBlock(src *Source) (err error) {
+	c.commands, err = src.parseBody(c)
+	return
+}
+
+func (c *RepeatCmd) Exec(vm *VM) error {
+	n, err := toFloat64(c.RunExprIndexOf(0, vm))
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < int(n); i++ {
+		err = vm.execCommands(c.commands)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RepeatCmd) CheckExpr(varType map[string]string) error {
+	return checkCondExpr(c.exprList, []int{1}, Float)
+}
+
+//ForCmd 'for' command, iterate over an array or from 0 to n-1
+type ForCmd struct {
+	*Cmd
+	commands []Commander
+}
+
+func NewForCmd(line int) *ForCmd {
+	return &ForCmd{
+		Cmd: NewCmd("for", "for @ID in <array|number> ... end", line),
+	}
+}
+
+func (c *ForCmd) IsKeyword(index int, word string) bool {
+	return index == 1 && word == "in"
+}
+
+func (c *ForCmd) ParseBlock(src *Source) (err error) {
+	c.commands, err = src.parseBody(c)
+	return
+}
+
+func (c *ForCmd) Exec(vm *VM) error {
+	v, _ := c.RunExprIndexOf(0, vm)
+	ID := v.(string)
+
+	values, err := c.RunExprIndexOf(2, vm)
+	if err != nil {
+		return err
+	}
+
+	var items []interface{}
+	switch values := values.(type) {
+	case float64:
+		for i := 0; i < int(values); i++ {
+			items = append(items, float64(i))
+		}
+	default:
+		rv := reflect.ValueOf(values)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return fmt.Errorf("cannot range over %v (type %T)", values, values)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i).Interface())
+		}
+	}
+
+	for _, item := range items {
+		err = vm.StoreVar(ID, item)
+		if err != nil {
+			return err
+		}
+		err = vm.execCommands(c.commands)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ForCmd) CheckExpr(varType map[string]string) error {
+	if len(c.exprList) != 3 {
+		return fmt.Errorf("num of expr must be 3, but it is %v", len(c.exprList))
+	}
+	if c.exprList[0].Type() != Identity || c.exprList[1].Type() != Keyword {
+		return fmt.Errorf("invaild cmd syntax: should be 'for @ID in <array|number>'")
+	}
+
+	//record loop variable type on source-parsing stage
+	ID := c.exprList[0].(*IDExpr).ID
+	switch c.exprList[2].Type() {
+	case Float:
+		varType[ID] = "float"
+	case Array:
+		varType[ID] = elemType(c.exprList[2])
+	case InternalVar:
+		varType[ID] = "internal"
+	default:
+		return fmt.Errorf("invalid cmd syntax: cannot range over %v", c.exprList[2].Type())
+	}
+	return nil
+}
+
+//WhileCmd 'while' command, run the body while the condition is true
+type WhileCmd struct {
+	*Cmd
+	commands []Commander
+}
+
+func NewWhileCmd(line int) *WhileCmd {
+	return &WhileCmd{
+		Cmd: NewCmd("while", "while <bool> ... end", line),
+	}
+}
+
+func (c *WhileCmd) ParseBlock(src *Source) (err error) {
+	c.commands, err = src.parseBody(c)
+	return
+}
+
+func (c *WhileCmd) Exec(vm *VM) error {
+	for {
+		ok, err := toBool(c.RunExprIndexOf(0, vm))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		err = vm.execCommands(c.commands)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *WhileCmd) CheckExpr(varType map[string]string) error {
+	return checkCondExpr(c.exprList, []int{1}, Bool)
+}
+
+//Walk call fn for commands in order of source, including commands nested in body of blocks, procedures,
+//cases and included files
+func Walk(commands []Commander, fn func(cmd Commander)) {
+	for _, cmd := range commands {
+		fn(cmd)
+		switch c := cmd.(type) {
+		case *IfCmd:
+			for _, b := range c.branches {
+				Walk(b.commands, fn)
+			}
+		case *RepeatCmd:
+			Walk(c.commands, fn)
+		case *ForCmd:
+			Walk(c.commands, fn)
+		case *WhileCmd:
+			Walk(c.commands, fn)
+		case *DefCmd:
+			Walk(c.commands, fn)
+		case *CaseCmd:
+			Walk(c.commands, fn)
+		case *SetupCmd:
+			Walk(c.commands, fn)
+		case *TeardownCmd:
+			Walk(c.commands, fn)
+		case *IncludeCmd:
+			Walk(c.commands, fn)
+		}
+	}
+}
+
+//elemType type of elements if all elements of array literal are same type, otherwise internal
+func elemType(array ExprNode) string {
+	var typ ExprType
+	for i, elem := range array.Children() {
+		if i > 0 && elem.Type() != typ {
+			return "internal"
+		}
+		typ = elem.Type()
+	}
+
+	switch typ {
+	case Bool:
+		return "bool"
+	case Float:
+		return "float"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	}
+	return "internal"
+}
@@ -0,0 +1,98 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil"
+	"github.com/hzxiao/goutil/assert"
+	"testing"
+)
+
+func TestForCmd_Exec(t *testing.T) {
+	src := newSourceByBytes([]byte(`let @s ""
+for @item in $(resp.body.list)
+    let @s "$(s)$(item),"
+end`))
+	cmds, err := src.Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, "internal", src.varType["item"])
+
+	vm := NewVM(cmds)
+	vm.StoreVar("resp", goutil.Map{
+		"body": goutil.Map{
+			"list": []interface{}{"a", "b", 1.0},
+		},
+	})
+	err = vm.Run()
+	assert.NoError(t, err)
+
+	s, _ := vm.StringV("s")
+	assert.Equal(t, "a,b,1,", s)
+}
+
+func TestForCmd_CheckExpr(t *testing.T) {
+	var invalid = []string{
+		"for @i 3\nend",
+		"for @i of 3\nend",
+		"for i in 3\nend",
+		`for @i in "abc"` + "\nend",
+		"for @i in 3\nelse\nend",
+		"repeat 3",
+		"while 1\nend",
+	}
+	for _, text := range invalid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestDefCmd_CheckExpr(t *testing.T) {
+	src := newSourceByBytes([]byte(`def f @a @b
+    echo $(a) $(b)
+end
+call f 1 "2"`))
+	_, err := src.Parse()
+	assert.NoError(t, err)
+	_, exist := src.varType["a"]
+	assert.False(t, exist)
+
+	var invalid = []string{
+		"def f @a\nend\ncall f",
+		"def f @a\nend\ncall f 1 2",
+		"call g 1",
+		"def f @a @a\nend",
+		"def f\nend\ndef f\nend",
+		"def @f\nend",
+		"def f @a\nend\necho $(a)",
+		"def f\ncall f @x\nend",
+	}
+	for _, text := range invalid {
+		_, err = newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestCheckCondExpr(t *testing.T) {
+	var valid = []string{
+		"if $(resp.body.ok)\nend",
+		"while $(resp.body.ok)\nend",
+		"repeat $(resp.body.count)\nend",
+		"gt $(resp.body.balance) 0",
+		`match $(resp.body.name) "^neo"`,
+		"def f @to @amount\n    tx-vout \"neo\" $(to) $(amount)\nend",
+	}
+	for _, text := range valid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.NoError(t, err)
+	}
+
+	//internal variables are only accepted as any type by block and assertion commands
+	var invalid = []string{
+		"tx-fee $(resp.body.fee)",
+		"tx-v $(tx.version)",
+		`tx-vout "neo" "AWSuQXpjuY3v22gCbEFL2vHbSLMMVK1QD6" $(resp.body.amount)`,
+		`match "neo" $(resp.body.pattern)`,
+	}
+	for _, text := range invalid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
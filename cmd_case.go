@@ -0,0 +1,225 @@
+package neotest
+
+import (
+	"fmt"
+	"github.com/hzxiao/goutil"
+	"time"
+)
+
+var _ Block = new(CaseCmd)
+var _ Block = new(SetupCmd)
+var _ Block = new(TeardownCmd)
+
+//CaseResult result of running a test case
+type CaseResult struct {
+	Name       string
+	File       string
+	Line       int
+	Duration   time.Duration
+	Assertions []*Assertion
+	TxHashes   []string
+	Steps      []*Step
+	Err        error
+}
+
+//Pass whether the case runs without error and failed assertion
+func (r *CaseResult) Pass() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, a := range r.Assertions {
+		if !a.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+//Cases results of all cases run by vm
+func (vm *VM) Cases() []*CaseResult {
+	return vm.cases
+}
+
+//CaseCmd 'case' command, a named test case which runs in its own variable scope
+type CaseCmd struct {
+	*Cmd
+	commands []Commander
+	data     *DataCmd
+}
+
+func NewCaseCmd(line int) *CaseCmd {
+	return &CaseCmd{
+		Cmd: NewCmd("case", "case <name> ... end", line),
+	}
+}
+
+//CaseName name of case if it's a string literal without variable
+func (c *CaseCmd) CaseName() (string, bool) {
+	expr, ok := c.exprList[0].(*stringExpr)
+	if !ok || len(expr.Variables()) > 0 {
+		return "", false
+	}
+	return expr.val, true
+}
+
+func (c *CaseCmd) ParseBlock(src *Source) (err error) {
+	err = checkTopLevel(src, c)
+	if err != nil {
+		return
+	}
+
+	//variables declared in case are not visible outside
+	varType := src.saveVarType()
+	c.commands, err = src.parseBody(c)
+	src.restoreVarType(varType)
+	if err != nil {
+		return
+	}
+
+	c.data, err = findData(c.commands)
+	if err != nil {
+		return blockError(c, err)
+	}
+	return
+}
+
+//Exec run setup, body and teardown of case, once per row if it has data. The error of case
+//is recorded in result instead of being returned, so that the failure of one case does not stop the others
+func (c *CaseCmd) Exec(vm *VM) error {
+	name, err := toString(c.RunExprIndexOf(0, vm))
+	if err != nil {
+		return err
+	}
+	if vm.CaseFilter != nil && !vm.CaseFilter(name) {
+		return nil
+	}
+
+	run := func() error {
+		err := vm.execCommands(vm.setup)
+		if err == nil {
+			err = vm.execCommands(c.commands)
+		}
+		//teardown always runs
+		if tdErr := vm.execCommands(vm.teardown); err == nil {
+			err = tdErr
+		}
+		return err
+	}
+
+	if c.data == nil {
+		vm.cases = append(vm.cases, vm.runCase(name+vm.rowLabel, c.line, nil, run))
+		return nil
+	}
+	for i, row := range c.data.rows {
+		label := fmt.Sprintf("%v[row %v]", vm.rowLabel, i+1)
+		vm.cases = append(vm.cases, vm.runCase(name+label, c.line, row, run))
+	}
+	return nil
+}
+
+//runCase run fn in an isolated variable scope with the variables of row, and return the result
+func (vm *VM) runCase(name string, line int, row goutil.Map, fn func() error) *CaseResult {
+	result := &CaseResult{Name: name, File: vm.File, Line: line}
+	start := time.Now()
+	n, m, k := len(vm.assertions), len(vm.txHashes), len(vm.steps)
+
+	variable := vm.variable
+	vm.variable = goutil.Map{}
+	for k, v := range variable {
+		vm.variable[k] = v
+	}
+	for k, v := range row {
+		vm.variable[k] = v
+	}
+
+	err := fn()
+	if e, ok := err.(*ExecError); ok && e.File == "" {
+		e.File = vm.File
+	}
+
+	vm.variable = variable
+	vm.CurTx = nil
+	vm.CurHttpReq = nil
+
+	result.Duration = time.Since(start)
+	result.Assertions = vm.assertions[n:]
+	result.TxHashes = vm.txHashes[m:]
+	result.Steps = vm.steps[k:]
+	result.Err = err
+	return result
+}
+
+func (c *CaseCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{1}, String)
+}
+
+//SetupCmd 'setup' command, the body runs before each case after it
+type SetupCmd struct {
+	*Cmd
+	commands []Commander
+}
+
+func NewSetupCmd(line int) *SetupCmd {
+	return &SetupCmd{
+		Cmd: NewCmd("setup", "setup ... end", line),
+	}
+}
+
+func (c *SetupCmd) ParseBlock(src *Source) (err error) {
+	err = checkTopLevel(src, c)
+	if err != nil {
+		return
+	}
+	//variables declared in setup are visible in cases
+	c.commands, err = src.parseBody(c)
+	return
+}
+
+func (c *SetupCmd) Exec(vm *VM) error {
+	vm.setup = c.commands
+	return nil
+}
+
+func (c *SetupCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{0})
+}
+
+//TeardownCmd 'teardown' command, the body runs after each case after it, even if the case fails
+type TeardownCmd struct {
+	*Cmd
+	commands []Commander
+}
+
+func NewTeardownCmd(line int) *TeardownCmd {
+	return &TeardownCmd{
+		Cmd: NewCmd("teardown", "teardown ... end", line),
+	}
+}
+
+func (c *TeardownCmd) ParseBlock(src *Source) (err error) {
+	err = checkTopLevel(src, c)
+	if err != nil {
+		return
+	}
+	varType := src.saveVarType()
+	c.commands, err = src.parseBody(c)
+	src.restoreVarType(varType)
+	return
+}
+
+func (c *TeardownCmd) Exec(vm *VM) error {
+	vm.teardown = c.commands
+	return nil
+}
+
+func (c *TeardownCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{0})
+}
+
+//checkTopLevel block such as case can not be nested in other block
+func checkTopLevel(src *Source, block Commander) error {
+	if src.depth > 1 {
+		return src.fail(blockError(block, fmt.Errorf("%v must not be nested in other block", block.Name())))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil/assert"
+	"testing"
+)
+
+func TestCaseCmd_Exec(t *testing.T) {
+	text := `let @a 1
+setup
+    let @b 2
+end
+case "one"
+    let @a 10
+    let @c 3
+    equal $(b) 2
+end
+case "two"
+    equal $(a) 2
+end
+case "three"
+    equal $(a) 1
+end`
+	cmds, err := newSourceByBytes([]byte(text)).Parse()
+	assert.NoError(t, err)
+
+	vm := NewVM(cmds)
+	err = vm.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(vm.Cases()))
+	assert.True(t, vm.Cases()[0].Pass())
+	assert.False(t, vm.Cases()[1].Pass())
+	assert.True(t, vm.Cases()[2].Pass())
+	assert.Equal(t, 1, len(vm.Cases()[1].Assertions))
+
+	a, _ := vm.Var("a")
+	assert.Equal(t, float64(1), a)
+	_, exist := vm.Var("b")
+	assert.False(t, exist)
+	_, exist = vm.Var("c")
+	assert.False(t, exist)
+}
+
+func TestCaseCmd_Parse(t *testing.T) {
+	var invalid = []string{
+		"case 1\nend",
+		"case \"a\"",
+		"if true\ncase \"a\"\nend\nend",
+		"case \"a\"\nsetup\nend\nend",
+		"case \"a\"\nlet @x 1\nend\necho $(x)",
+	}
+	for _, text := range invalid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,216 @@
+package neotest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/hzxiao/goutil"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//decimalRegexp plain decimal literal, values like NaN, Inf or hex float are kept as string
+var decimalRegexp = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][-+]?\d+)?$`)
+
+var _ Linker = new(DataCmd)
+
+//DataCmd 'data' command, run the enclosing file or case once per row of csv or json fixtures,
+//and columns of row are bound as variables
+type DataCmd struct {
+	*Cmd
+	file    string
+	columns []string
+	rows    []goutil.Map
+}
+
+func NewDataCmd(line int) *DataCmd {
+	return &DataCmd{
+		Cmd: NewCmd("data", "data <csv-or-json-file>", line),
+	}
+}
+
+//Link load fixtures and record types of columns on source-parsing stage
+func (c *DataCmd) Link(src *Source) error {
+	if len(src.includes) > 1 {
+		return fmt.Errorf("data is not allowed in included file")
+	}
+	if _, ok := src.block.(*CaseCmd); src.block != nil && !ok {
+		return fmt.Errorf("data must be at top level of file or case")
+	}
+
+	c.file = c.exprList[0].(*stringExpr).val
+	if !filepath.IsAbs(c.file) && src.file != "" {
+		c.file = filepath.Join(filepath.Dir(src.file), c.file)
+	}
+
+	var err error
+	switch filepath.Ext(c.file) {
+	case ".csv":
+		c.columns, c.rows, err = loadCSV(c.file)
+	case ".json":
+		c.columns, c.rows, err = loadJSON(c.file)
+	default:
+		return fmt.Errorf("unsupported data file %v, should be .csv or .json", c.file)
+	}
+	if err != nil {
+		return err
+	}
+	if len(c.rows) == 0 {
+		return fmt.Errorf("no row in data file %v", c.file)
+	}
+
+	for _, col := range c.columns {
+		if !ValidID(col) {
+			return fmt.Errorf("invalid column name %v in %v", col, c.file)
+		}
+		src.varType[col] = columnType(col, c.rows)
+	}
+	return nil
+}
+
+//Exec do nothing, the rows are bound by the enclosing file or case
+func (c *DataCmd) Exec(vm *VM) error {
+	return nil
+}
+
+func (c *DataCmd) CheckExpr(varType map[string]string) error {
+	err := checkExprNumAndType(c.exprList, []int{1}, String)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.exprList[0].(*stringExpr); !ok {
+		return fmt.Errorf("file must be a string literal")
+	}
+	if len(c.exprList[0].(Variate).Variables()) > 0 {
+		return fmt.Errorf("file must not contain variable")
+	}
+	return nil
+}
+
+//findData find the only data command in commands
+func findData(commands []Commander) (*DataCmd, error) {
+	var data *DataCmd
+	for _, cmd := range commands {
+		if d, ok := cmd.(*DataCmd); ok {
+			if data != nil {
+				return nil, fmt.Errorf("data redeclared at line %v", d.line)
+			}
+			data = d
+		}
+	}
+	return data, nil
+}
+
+//loadCSV load csv file whose first record is the header, a value is typed as number or bool if it can be
+func loadCSV(file string) ([]string, []goutil.Map, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("no header in data file %v", file)
+	}
+
+	columns := records[0]
+	var rows []goutil.Map
+	for _, record := range records[1:] {
+		row := goutil.Map{}
+		for i, col := range columns {
+			row[col] = csvValue(record[i])
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+func csvValue(s string) interface{} {
+	if decimalRegexp.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	return s
+}
+
+//loadJSON load json file which is an array of objects, columns are keys of all objects
+func loadJSON(file string) ([]string, []goutil.Map, error) {
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	var objects []map[string]interface{}
+	err = json.Unmarshal(bs, &objects)
+	if err != nil {
+		return nil, nil, fmt.Errorf("data file %v should be an array of objects: %v", file, err)
+	}
+
+	var columns []string
+	var rows []goutil.Map
+	for _, obj := range objects {
+		row := jsonValue(obj).(goutil.Map)
+		for col := range row {
+			if !containsString(columns, col) {
+				columns = append(columns, col)
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Strings(columns)
+	return columns, rows, nil
+}
+
+//jsonValue convert objects in json value into goutil.Map as map literal
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := goutil.Map{}
+		for k, item := range v {
+			m[k] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	}
+	return v
+}
+
+//columnType type of column if values of all rows are same type, otherwise internal
+func columnType(col string, rows []goutil.Map) string {
+	var typ string
+	for i, row := range rows {
+		t := "internal"
+		switch row[col].(type) {
+		case float64:
+			t = "float"
+		case bool:
+			t = "bool"
+		case string:
+			t = "string"
+		case []interface{}:
+			t = "array"
+		case goutil.Map:
+			t = "map"
+		}
+		if i > 0 && t != typ {
+			return "internal"
+		}
+		typ = t
+	}
+	return typ
+}
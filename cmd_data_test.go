@@ -0,0 +1,90 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil/assert"
+	"testing"
+)
+
+func TestLoadData(t *testing.T) {
+	columns, rows, err := loadCSV("testdata/data/transfer.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"to", "amount", "ok"}, columns)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, float64(0.5), rows[2]["amount"])
+	assert.Equal(t, false, rows[1]["ok"])
+	assert.Equal(t, "float", columnType("amount", rows))
+
+	columns, rows, err = loadJSON("testdata/data/accounts.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"assets", "balance", "extra", "name"}, columns)
+	assert.Equal(t, "map", columnType("extra", rows))
+	assert.Equal(t, "array", columnType("assets", rows))
+}
+
+func TestDataCmd_Exec(t *testing.T) {
+	src, err := NewSource("testdata/data_file.ntf")
+	assert.NoError(t, err)
+	cmds, err := src.Parse()
+	assert.NoError(t, err)
+
+	vm := NewVM(cmds)
+	vm.File = "data_file.ntf"
+	err = vm.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(vm.Cases()))
+	assert.Equal(t, "data_file.ntf[row 2]", vm.Cases()[1].Name)
+	assert.Equal(t, 3, len(vm.Cases()[1].Assertions))
+	_, exist := vm.Var("balance")
+	assert.False(t, exist)
+
+	src, err = NewSource("testdata/data.ntf")
+	assert.NoError(t, err)
+	cmds, err = src.Parse()
+	assert.NoError(t, err)
+	vm = NewVM(cmds)
+	err = vm.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(vm.Cases()))
+	assert.Equal(t, "transfer[row 3]", vm.Cases()[2].Name)
+	assert.Equal(t, "no data", vm.Cases()[3].Name)
+
+	//failed row which has cases keeps its own assertions
+	src, err = NewSource("testdata/data_case.ntf")
+	assert.NoError(t, err)
+	cmds, err = src.Parse()
+	assert.NoError(t, err)
+	vm = NewVM(cmds)
+	vm.File = "data_case.ntf"
+	err = vm.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(vm.Cases()))
+	assert.Equal(t, "to[row 2]", vm.Cases()[1].Name)
+	assert.Equal(t, "data_case.ntf[row 2]", vm.Cases()[2].Name)
+	assert.Equal(t, 1, len(vm.Cases()[2].Assertions))
+	assert.False(t, vm.Cases()[2].Assertions[0].Pass)
+}
+
+func TestDataCmd_Parse(t *testing.T) {
+	var invalid = []string{
+		`data "testdata/data/none.csv"`,
+		`data "testdata/data.ntf"`,
+		"data \"testdata/data/transfer.csv\"\ndata \"testdata/data/transfer.csv\"",
+		"if true\ndata \"testdata/data/transfer.csv\"\nend",
+		"case \"a\"\ndata \"testdata/data/transfer.csv\"\nequal $(name) 1\nend",
+		"case \"a\"\ndata \"testdata/data/transfer.csv\"\nend\necho $(amount)",
+	}
+	for _, text := range invalid {
+		_, err := newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestCsvValue(t *testing.T) {
+	assert.Equal(t, float64(1), csvValue("1"))
+	assert.Equal(t, -0.5, csvValue("-0.5"))
+	assert.Equal(t, 1e8, csvValue("1e8"))
+	assert.Equal(t, true, csvValue("true"))
+	for _, s := range []string{"NaN", "Inf", "-inf", "infinity", "0x1p4", "1_000", ".5", "+1", "abc"} {
+		assert.Equal(t, s, csvValue(s))
+	}
+}
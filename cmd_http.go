@@ -117,8 +117,8 @@ func NewRetCmd(line int) *RetCmd {
 }
 
 func (c *RetCmd) Exec(vm *VM) error {
-	if len(c.exprList) != 1 {
-		return fmt.Errorf("num of expr must be 1, but it is %v", len(c.exprList))
+	if len(c.exprList) > 1 {
+		return fmt.Errorf("num of expr must be 1 or 0, but it is %v", len(c.exprList))
 	}
 
 	if vm.CurHttpReq == nil {
@@ -131,15 +131,13 @@ func (c *RetCmd) Exec(vm *VM) error {
 	}
 
 	if len(c.exprList) > 0 {
-		v, err := c.exprList[0].Run(vm)
+		v, err := toFloat64(c.exprList[0].Run(vm))
 		if err != nil {
 			return err
 		}
 
 		actual, _ := vm.FloatV("resp.code")
-		if v.(float64) != actual {
-			//TODO record
-		}
+		return vm.Assert(c, v == actual, actual, v, fmt.Sprintf("status code %v != %v", actual, v))
 	}
 	return nil
 }
@@ -0,0 +1,29 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRetCmd_Exec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	cmds, err := newSourceByBytes([]byte(`req "GET" "` + server.URL + `"
+ret 200`)).Parse()
+	assert.NoError(t, err)
+	vm := NewVM(cmds)
+	assert.NoError(t, vm.Run())
+	assert.Equal(t, 1, len(vm.Assertions()))
+	assert.True(t, vm.Assertions()[0].Pass)
+
+	//status code which is not number is an error instead of panic
+	cmds[1].(*RetCmd).exprList[0] = newStringExpr("200")
+	vm = NewVM(cmds)
+	assert.Error(t, vm.Run())
+	assert.Equal(t, 0, len(vm.Assertions()))
+}
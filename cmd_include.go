@@ -0,0 +1,52 @@
+package neotest
+
+import (
+	"fmt"
+)
+
+var _ Linker = new(IncludeCmd)
+
+//IncludeCmd 'include' command, include another source file
+type IncludeCmd struct {
+	*Cmd
+	file     string
+	commands []Commander
+}
+
+func NewIncludeCmd(line int) *IncludeCmd {
+	return &IncludeCmd{
+		Cmd: NewCmd("include", "include <file>", line),
+	}
+}
+
+func (c *IncludeCmd) Link(src *Source) (err error) {
+	path := c.exprList[0].(*stringExpr).val
+	c.commands, c.file, err = src.include(path)
+	return
+}
+
+func (c *IncludeCmd) Exec(vm *VM) error {
+	file := vm.File
+	vm.File = c.file
+	defer func() { vm.File = file }()
+
+	err := vm.execCommands(c.commands)
+	if e, ok := err.(*ExecError); ok && e.File == "" {
+		e.File = c.file
+	}
+	return err
+}
+
+func (c *IncludeCmd) CheckExpr(varType map[string]string) error {
+	err := checkExprNumAndType(c.exprList, []int{1}, String)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.exprList[0].(*stringExpr); !ok {
+		return fmt.Errorf("file must be a string literal")
+	}
+	if len(c.exprList[0].(Variate).Variables()) > 0 {
+		return fmt.Errorf("file must not contain variable")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package neotest
+
+import (
+	"fmt"
+	"github.com/hzxiao/goutil"
+)
+
+var _ Block = new(DefCmd)
+var _ Keyworder = new(DefCmd)
+var _ Keyworder = new(CallCmd)
+var _ Linker = new(CallCmd)
+
+//DefCmd 'def' command, define a procedure with parameters
+type DefCmd struct {
+	*Cmd
+	params   []string
+	commands []Commander
+	file     string //file where the procedure is defined
+}
+
+func NewDefCmd(line int) *DefCmd {
+	return &DefCmd{
+		Cmd: NewCmd("def", "def <name> @arg1 @arg2 ... end", line),
+	}
+}
+
+func (c *DefCmd) IsKeyword(index int, word string) bool {
+	return index == 0 && ValidID(word)
+}
+
+//ProcName name of the procedure
+func (c *DefCmd) ProcName() string {
+	return c.exprList[0].(*keywordExpr).word
+}
+
+//Params parameters of the procedure
+func (c *DefCmd) Params() []string {
+	return c.params
+}
+
+func (c *DefCmd) ParseBlock(src *Source) (err error) {
+	name := c.ProcName()
+	if _, exist := src.procs[name]; exist {
+		err = src.fail(blockError(c, fmt.Errorf("procedure %v redeclared", name)))
+		if err != nil {
+			return
+		}
+	}
+	//register before parsing body so that procedure can call itself
+	src.procs[name] = c
+	c.file = src.file
+
+	//parameters are only visible in body
+	shadowed := make(map[string]string)
+	for _, param := range c.params {
+		if typ, exist := src.varType[param]; exist {
+			shadowed[param] = typ
+		}
+		src.varType[param] = "internal"
+	}
+	c.commands, err = src.parseBody(c)
+	for _, param := range c.params {
+		delete(src.varType, param)
+		if typ, exist := shadowed[param]; exist {
+			src.varType[param] = typ
+		}
+	}
+	return
+}
+
+func (c *DefCmd) Exec(vm *VM) error {
+	return nil
+}
+
+func (c *DefCmd) CheckExpr(varType map[string]string) error {
+	if len(c.exprList) == 0 || c.exprList[0].Type() != Keyword {
+		return fmt.Errorf("invaild cmd syntax: the first argument should be procedure name")
+	}
+
+	c.params = nil
+	for _, expr := range c.exprList[1:] {
+		if expr.Type() != Identity {
+			return fmt.Errorf("invaild cmd syntax: parameter should be @ID")
+		}
+		param := expr.(*IDExpr).ID
+		for _, p := range c.params {
+			if p == param {
+				return fmt.Errorf("duplicate parameter: %v", param)
+			}
+		}
+		c.params = append(c.params, param)
+	}
+	return nil
+}
+
+//CallCmd 'call' command, call a procedure defined by 'def'
+type CallCmd struct {
+	*Cmd
+	proc *DefCmd
+	file string //file where the procedure is called
+}
+
+func NewCallCmd(line int) *CallCmd {
+	return &CallCmd{
+		Cmd: NewCmd("call", "call <name> <object1> <object2> ...", line),
+	}
+}
+
+func (c *CallCmd) IsKeyword(index int, word string) bool {
+	return index == 0 && ValidID(word)
+}
+
+func (c *CallCmd) Link(src *Source) error {
+	name := c.exprList[0].(*keywordExpr).word
+	proc, exist := src.procs[name]
+	if !exist {
+		return fmt.Errorf("undefined procedure: %v", name)
+	}
+
+	if len(proc.params) != len(c.exprList)-1 {
+		return fmt.Errorf("procedure %v expects %v arguments, but it is %v", name, len(proc.params), len(c.exprList)-1)
+	}
+	c.proc = proc
+	c.file = src.file
+	return nil
+}
+
+func (c *CallCmd) Exec(vm *VM) error {
+	if c.proc == nil {
+		return fmt.Errorf("procedure is not linked")
+	}
+
+	args := goutil.Map{}
+	for i, param := range c.proc.params {
+		v, err := c.RunExprIndexOf(i+1, vm)
+		if err != nil {
+			return err
+		}
+		args.Set(param, v)
+	}
+
+	err := vm.pushScope(args)
+	if err != nil {
+		return err
+	}
+	defer vm.popScope()
+
+	//body runs in the file where the procedure is defined, e.g. an included file
+	if c.proc.file == c.file {
+		return vm.execCommands(c.proc.commands)
+	}
+	file := vm.File
+	vm.File = c.proc.file
+	defer func() { vm.File = file }()
+
+	err = vm.execCommands(c.proc.commands)
+	if e, ok := err.(*ExecError); ok && e.File == "" {
+		e.File = c.proc.file
+	}
+	return err
+}
+
+func (c *CallCmd) CheckExpr(varType map[string]string) error {
+	if len(c.exprList) == 0 || c.exprList[0].Type() != Keyword {
+		return fmt.Errorf("invaild cmd syntax: the first argument should be procedure name")
+	}
+
+	for _, expr := range c.exprList[1:] {
+		if expr.Type() == Identity || expr.Type() == Keyword {
+			return fmt.Errorf("invaild cmd syntax: invalid argument type %v", expr.Type())
+		}
+	}
+	return nil
+}
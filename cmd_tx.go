@@ -125,12 +125,12 @@ type TxFeeCmd struct {
 
 func NewTxFeeCmd(line int) *TxFeeCmd {
 	return &TxFeeCmd{
-		Cmd: NewCmd("tx-type", "tx-fee <number>", line),
+		Cmd: NewCmd("tx-fee", "tx-fee <number>|auto", line),
 	}
 }
 
 func (c *TxFeeCmd) Exec(vm *VM) error {
-	err := checkExprNumAndType(c.exprList, []int{1}, Float)
+	err := c.CheckExpr(nil)
 	if err != nil {
 		return err
 	}
@@ -139,16 +139,77 @@ func (c *TxFeeCmd) Exec(vm *VM) error {
 		return fmt.Errorf("there is no declare a tx before")
 	}
 
+	vm.CurTx.Param.AutoFee = isAuto(c.exprList)
+	if vm.CurTx.Param.AutoFee {
+		return nil
+	}
 	fee, err := toFloat64(c.RunExprIndexOf(0, vm))
 	if err != nil {
 		return err
 	}
-	vm.CurTx.SetFee(fee)
+	return vm.CurTx.SetFee(fee)
+}
 
-	return nil
+func (c *TxFeeCmd) IsKeyword(index int, word string) bool {
+	return index == 0 && word == "auto"
 }
 
 func (c *TxFeeCmd) CheckExpr(varType map[string]string) error {
+	if isAuto(c.exprList) {
+		return nil
+	}
+	return checkExprNumAndType(c.exprList, []int{1}, Float)
+}
+
+//isAuto whether the only argument is keyword 'auto'
+func isAuto(exprList []ExprNode) bool {
+	if len(exprList) != 1 {
+		return false
+	}
+	kw, ok := exprList[0].(*keywordExpr)
+	return ok && kw.word == "auto"
+}
+
+//TxSysFeeCmd neo tx system fee command, the gas of invocation tx
+type TxSysFeeCmd struct {
+	*Cmd
+}
+
+func NewTxSysFeeCmd(line int) *TxSysFeeCmd {
+	return &TxSysFeeCmd{
+		Cmd: NewCmd("tx-sysfee", "tx-sysfee <number>|auto", line),
+	}
+}
+
+func (c *TxSysFeeCmd) Exec(vm *VM) error {
+	err := c.CheckExpr(nil)
+	if err != nil {
+		return err
+	}
+
+	if vm.CurTx == nil {
+		return fmt.Errorf("there is no declare a tx before")
+	}
+
+	vm.CurTx.Param.AutoSysFee = isAuto(c.exprList)
+	if vm.CurTx.Param.AutoSysFee {
+		return nil
+	}
+	fee, err := toFloat64(c.RunExprIndexOf(0, vm))
+	if err != nil {
+		return err
+	}
+	return vm.CurTx.SetSysFee(fee)
+}
+
+func (c *TxSysFeeCmd) IsKeyword(index int, word string) bool {
+	return index == 0 && word == "auto"
+}
+
+func (c *TxSysFeeCmd) CheckExpr(varType map[string]string) error {
+	if isAuto(c.exprList) {
+		return nil
+	}
 	return checkExprNumAndType(c.exprList, []int{1}, Float)
 }
 
@@ -295,6 +356,43 @@ func (c *TxVoutCmd) CheckExpr(varType map[string]string) error {
 	return checkExprNumAndType(c.exprList, []int{3}, String, String, Float)
 }
 
+//TxClaimCmd neo tx claim command, claim the unclaimed gas of initiator
+type TxClaimCmd struct {
+	*Cmd
+}
+
+func NewTxClaimCmd(line int) *TxClaimCmd {
+	return &TxClaimCmd{
+		Cmd: NewCmd("tx-claim", "tx-claim [<address>]", line),
+	}
+}
+
+func (c *TxClaimCmd) Exec(vm *VM) error {
+	err := checkExprNumAndType(c.exprList, []int{0, 1}, String)
+	if err != nil {
+		return err
+	}
+
+	if vm.CurTx == nil {
+		return fmt.Errorf("there is no declare a tx before")
+	}
+
+	var address string
+	if len(c.exprList) > 0 {
+		address, err = toString(c.RunExprIndexOf(0, vm))
+		if err != nil {
+			return err
+		}
+	}
+	vm.CurTx.Param.Claim = true
+	vm.CurTx.Param.ClaimTo = address
+	return nil
+}
+
+func (c *TxClaimCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{0, 1}, String)
+}
+
 //TxInvokeCmd neo tx invoke command
 type TxInvokeCmd struct {
 	*Cmd
@@ -394,6 +492,48 @@ func (c *TxInvokeScriptCmd) CheckExpr(varType map[string]string) error {
 	return checkExprNumAndType(c.exprList, []int{1}, String)
 }
 
+//TxInvokeTestCmd neo tx invoke test command, it runs script of tx by node without sending
+type TxInvokeTestCmd struct {
+	*Cmd
+}
+
+func NewTxInvokeTestCmd(line int) *TxInvokeTestCmd {
+	return &TxInvokeTestCmd{
+		Cmd: NewCmd("tx-invoke-test", "tx-invoke-test <node>", line),
+	}
+}
+
+func (c *TxInvokeTestCmd) Exec(vm *VM) error {
+	err := checkExprNumAndType(c.exprList, []int{1}, String)
+	if err != nil {
+		return err
+	}
+	if vm.CurTx == nil {
+		return fmt.Errorf("there is no declare a tx before")
+	}
+	if len(vm.CurTx.Param.Script) == 0 {
+		return fmt.Errorf("there is no script to invoke")
+	}
+
+	node, err := toString(c.RunExprIndexOf(0, vm))
+	if err != nil {
+		return err
+	}
+
+	//tx is not completed, so it's not labeled by hash which is cached once computed
+	pln.FInfoVerbose(vm.Out, "test invoke script %x by %v", vm.CurTx.Param.Script, node)
+	res, err := neo.InvokeTest(vm.rpcClient(node), vm.CurTx.Param.Script)
+	if err != nil {
+		return err
+	}
+	vm.StoreVar("invoke", res)
+	return nil
+}
+
+func (c *TxInvokeTestCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{1}, String)
+}
+
 //TxWitnessCmd neo tx witness command
 type TxWitnessCmd struct {
 	*Cmd
@@ -465,21 +605,22 @@ func (c *TxSendCmd) Exec(vm *VM) error {
 		return err
 	}
 
-	err = vm.CurTx.Complete(node)
+	err = vm.CurTx.Complete(vm.rpcClient(node))
 	if err != nil {
 		return err
 	}
+	vm.traceTx(vm.CurTx)
 
 	s, _ := json.MarshalIndent(vm.CurTx.ToMap(), "", "  ")
-	pln.InfoVerbose("tx %v: %v", vm.CurTx.Label(), string(s))
+	pln.FInfoVerbose(vm.Out, "tx %v: %v", vm.CurTx.Label(), string(s))
 
-	pln.InfoVerbose("send tx %v to %v", vm.CurTx.Label(), node)
+	pln.FInfoVerbose(vm.Out, "send tx %v to %v", vm.CurTx.Label(), node)
 	err = vm.SendTx(node)
 	if err != nil {
 		return err
 	}
 
-	pln.InfoVerbose("wait for tx %v...", vm.CurTx.Label())
+	pln.FInfoVerbose(vm.Out, "wait for tx %v...", vm.CurTx.Label())
 	err = vm.WaitTx(node)
 	if err != nil {
 		return err
@@ -0,0 +1,148 @@
+package neotest
+
+import (
+	"bytes"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/hzxiao/goutil/assert"
+	"github.com/hzxiao/neotest/pkg/neo"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTxFeeCmd_Auto(t *testing.T) {
+	cmds, err := newSourceByBytes([]byte(`tx "invoke"
+tx-fee auto
+tx-sysfee auto`)).Parse()
+	assert.NoError(t, err)
+	vm := NewVM(cmds)
+	assert.NoError(t, vm.Run())
+	assert.True(t, vm.CurTx.Param.AutoFee)
+	assert.True(t, vm.CurTx.Param.AutoSysFee)
+
+	cmds, err = newSourceByBytes([]byte(`tx "invoke"
+tx-fee auto
+tx-fee 0.5
+tx-sysfee 2`)).Parse()
+	assert.NoError(t, err)
+	vm = NewVM(cmds)
+	assert.NoError(t, vm.Run())
+	assert.False(t, vm.CurTx.Param.AutoFee)
+	assert.Equal(t, util.Fixed8(0.5e8), vm.CurTx.Param.Fee)
+	assert.Equal(t, util.Fixed8(2e8), vm.CurTx.Param.SysFee)
+
+	for _, text := range []string{`tx-fee "auto"`, `tx-fee auto 1`, `tx-sysfee manual`} {
+		_, err = newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+
+	cmds, err = newSourceByBytes([]byte(`tx "invoke"
+tx-sysfee 0.5`)).Parse()
+	assert.NoError(t, err)
+	assert.Error(t, NewVM(cmds).Run())
+
+	//fee has at most 8 decimals
+	cmds, err = newSourceByBytes([]byte(`tx "invoke"
+tx-fee 0.123456789`)).Parse()
+	assert.NoError(t, err)
+	assert.Error(t, NewVM(cmds).Run())
+}
+
+func TestTxInvokeTestCmd_Exec(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"state":"HALT, BREAK","gas_consumed":"0.338",
+"stack":[{"type":"ByteArray","value":"00e1f505"}]}}`))
+	}))
+	defer node.Close()
+
+	cmds, err := newSourceByBytes([]byte(`let @node "` + node.URL + `"
+tx "balance"
+tx-invokefunc '["0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9", "balanceOf", [{"type": "Hash160", "value": "5e40b22e86dc6ff4a7b0416450971469fe71040d"}]]'
+tx-invoke-test $(node)
+equal $(invoke.state) "HALT, BREAK"
+equal $(invoke.stack.0.integer) 100000000
+equal $(invoke.stack.0.integer_str) "100000000"
+equal $(invoke.stack[0].value) "00e1f505"`)).Parse()
+	assert.NoError(t, err)
+	vm := NewVM(cmds)
+	assert.NoError(t, vm.Run())
+	assert.Equal(t, 4, len(vm.Assertions()))
+	for _, a := range vm.Assertions() {
+		assert.True(t, a.Pass)
+	}
+
+	//hash of unnamed tx is not computed before completing
+	cmds, err = newSourceByBytes([]byte(`tx
+tx-invokescript "51"
+tx-invoke-test "` + node.URL + `"`)).Parse()
+	assert.NoError(t, err)
+	vm = NewVM(cmds)
+	assert.NoError(t, vm.Run())
+	vm.CurTx.Version = 1
+	expected := neo.NewTx("")
+	expected.Version = 1
+	assert.Equal(t, expected.Hash(), vm.CurTx.Hash())
+
+	//no script
+	cmds, err = newSourceByBytes([]byte(`tx "balance"
+tx-invoke-test "http://127.0.0.1:1"`)).Parse()
+	assert.NoError(t, err)
+	assert.Error(t, NewVM(cmds).Run())
+}
+
+func TestVM_WaitTxAppLog(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "getapplicationlog") {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"executions":[{"trigger":"Application",
+"vmstate":"HALT, BREAK","gas_consumed":"2.855","stack":[],"notifications":[{"contract":"0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9",
+"state":{"type":"Array","value":[{"type":"ByteArray","value":"7472616e73666572"},{"type":"ByteArray","value":""},
+{"type":"ByteArray","value":"5e40b22e86dc6ff4a7b0416450971469fe71040d"},{"type":"ByteArray","value":"00e1f505"}]}}]}]}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"txid":"0x01"}}`))
+	}))
+	defer node.Close()
+
+	vm := NewVM(nil)
+	vm.CurTx = neo.NewTx("invoke")
+	assert.NoError(t, vm.CurTx.SetType("invocation"))
+	assert.NoError(t, vm.WaitTx(node.URL))
+
+	state, _ := vm.StringV("tx.applog.vmstate")
+	assert.Equal(t, "HALT, BREAK", state)
+	gas, _ := vm.Var("tx.applog.gas_consumed")
+	assert.Equal(t, 2.855, gas)
+	event, _ := vm.StringV("tx.applog.notifications.0.event")
+	assert.Equal(t, "transfer", event)
+	amount, _ := vm.Var("tx.applog.notifications[0].amount")
+	assert.Equal(t, "100000000", amount)
+}
+
+func TestVM_WaitTxAppLogUnavailable(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "getapplicationlog") {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"txid":"0x01"}}`))
+	}))
+	defer node.Close()
+
+	vm := NewVM(nil)
+	var buf bytes.Buffer
+	vm.Out = &buf
+	vm.CurTx = neo.NewTx("invoke")
+	assert.NoError(t, vm.CurTx.SetType("invocation"))
+	assert.NoError(t, vm.WaitTx(node.URL))
+
+	assert.True(t, strings.Contains(buf.String(), "application log of tx invoke is unavailable"))
+	msg, ok := vm.StringV("tx.applog.error")
+	assert.True(t, ok)
+	assert.True(t, strings.Contains(msg, "Method not found"))
+	txid, _ := vm.StringV("tx.txid")
+	assert.Equal(t, "0x01", txid)
+}
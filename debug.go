@@ -0,0 +1,168 @@
+package neotest
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+//ErrDebugQuit error returned by command when debugger quits, it stops the vm
+var ErrDebugQuit = errors.New("quit debugging")
+
+type debugMode int
+
+const (
+	debugContinue debugMode = iota
+	debugStep
+	debugNext
+)
+
+//Debugger pause vm before commands at breakpoints or on stepping, the state of vm can be inspected at the pause point
+type Debugger struct {
+	VM *VM
+	//Pause called when vm pauses before cmd, the vm resumes after it returns. It decides how to resume
+	//by calling Step, Next, Continue or Quit of debugger
+	Pause func(cmd Commander)
+
+	file        string
+	breakpoints map[string]bool
+	mode        debugMode
+	depth       int //depth of the paused command, for Next
+	quit        bool
+}
+
+//NewDebugger parse file for debugging, the vm pauses before the first command
+func NewDebugger(file string) (*Debugger, error) {
+	src, err := NewSource(file)
+	if err != nil {
+		return nil, err
+	}
+	cmds, err := src.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Debugger{
+		VM:          NewVM(cmds),
+		file:        file,
+		breakpoints: make(map[string]bool),
+		mode:        debugStep,
+	}
+	d.VM.File = file
+	d.VM.hook = d.beforeExec
+	return d, nil
+}
+
+//Run run the vm until it finishes or the debugger quits
+func (d *Debugger) Run() error {
+	err := d.VM.Run()
+	if d.quit {
+		return ErrDebugQuit
+	}
+	return err
+}
+
+func (d *Debugger) beforeExec(cmd Commander, depth int) error {
+	if d.quit {
+		return ErrDebugQuit
+	}
+
+	switch {
+	case d.mode == debugStep:
+	case d.mode == debugNext && depth <= d.depth:
+	case d.breakpoints[d.breakpoint(d.VM.File, cmd.Line())]:
+	default:
+		return nil
+	}
+
+	d.mode = debugContinue
+	d.depth = depth
+	if d.Pause != nil {
+		d.Pause(cmd)
+	}
+	if d.quit {
+		return ErrDebugQuit
+	}
+	return nil
+}
+
+//breakpoint key of breakpoint, the file is the main file if it's empty
+func (d *Debugger) breakpoint(file string, line int) string {
+	if file == "" {
+		file = d.file
+	}
+	return fmt.Sprintf("%v:%v", filepath.Clean(file), line)
+}
+
+//SetBreakpoint pause before the commands at line of file, file is relative to the main file
+//and it's the main file if empty
+func (d *Debugger) SetBreakpoint(file string, line int) {
+	d.breakpoints[d.breakpoint(d.resolve(file), line)] = true
+}
+
+//ClearBreakpoint remove the breakpoint, it returns false if the breakpoint is not set
+func (d *Debugger) ClearBreakpoint(file string, line int) bool {
+	key := d.breakpoint(d.resolve(file), line)
+	if !d.breakpoints[key] {
+		return false
+	}
+	delete(d.breakpoints, key)
+	return true
+}
+
+//Breakpoints breakpoints in form of file:line
+func (d *Debugger) Breakpoints() []string {
+	var list []string
+	for key := range d.breakpoints {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (d *Debugger) resolve(file string) string {
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(filepath.Dir(d.file), file)
+}
+
+//Step pause before the next command, it steps into blocks and procedures
+func (d *Debugger) Step() {
+	d.mode = debugStep
+}
+
+//Next pause before the next command which is not nested deeper than the paused one
+func (d *Debugger) Next() {
+	d.mode = debugNext
+}
+
+//Continue run until a breakpoint
+func (d *Debugger) Continue() {
+	d.mode = debugContinue
+}
+
+//Quit stop the vm
+func (d *Debugger) Quit() {
+	d.quit = true
+}
+
+//Eval evaluate expression at the pause point, every visible variable can be used in it
+func (d *Debugger) Eval(text string) (interface{}, error) {
+	src := newSourceByBytes(nil)
+	for k := range d.VM.variable {
+		src.varType[k] = "internal"
+	}
+	if len(d.VM.scopes) > 0 {
+		for k := range d.VM.scopes[len(d.VM.scopes)-1] {
+			src.varType[k] = "internal"
+		}
+	}
+
+	expr, err := src.ParseExpr(text)
+	if err != nil {
+		return nil, err
+	}
+	return expr.Run(d.VM)
+}
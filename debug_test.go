@@ -0,0 +1,86 @@
+package neotest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hzxiao/goutil/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestDebugger(t *testing.T) {
+	d, err := NewDebugger("testdata/debug.ntf")
+	assert.NoError(t, err)
+	d.VM.Out = &bytes.Buffer{}
+
+	var lines []int
+	var x, a interface{}
+	d.Pause = func(cmd Commander) {
+		lines = append(lines, cmd.Line())
+		switch cmd.Line() {
+		case 1:
+			d.SetBreakpoint("", 7)
+			d.SetBreakpoint("debug.ntf", 11)
+			d.Next()
+		case 2:
+			d.Next()
+		case 5:
+			d.Step()
+		case 3:
+			x, err = d.Eval("$(x)")
+			assert.NoError(t, err)
+			d.Continue()
+		case 7:
+			a, err = d.Eval("(( $(a) * 10 ))")
+			assert.NoError(t, err)
+			assert.True(t, d.ClearBreakpoint("", 7))
+			assert.False(t, d.ClearBreakpoint("", 7))
+			assert.Equal(t, []string{"testdata/debug.ntf:11"}, d.Breakpoints())
+			d.Continue()
+		case 11:
+			assert.Equal(t, "POST", d.VM.CurHttpReq.Method)
+			d.Continue()
+		}
+	}
+	err = d.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 5, 3, 7, 11}, lines)
+	assert.Equal(t, float64(1), x)
+	assert.Equal(t, float64(20), a)
+
+	//quit stops vm
+	d, err = NewDebugger("testdata/debug.ntf")
+	assert.NoError(t, err)
+	n := 0
+	d.Pause = func(cmd Commander) {
+		n++
+		d.Quit()
+	}
+	err = d.Run()
+	assert.Equal(t, ErrDebugQuit, err)
+	assert.Equal(t, 1, n)
+	_, ok := d.VM.Var("b")
+	assert.False(t, ok)
+}
+
+func TestDebugger_IncludedProc(t *testing.T) {
+	d, err := NewDebugger("testdata/debug/main.ntf")
+	assert.NoError(t, err)
+	d.VM.Out = &bytes.Buffer{}
+	d.SetBreakpoint("proc.ntf", 2)
+	d.SetBreakpoint("", 2)
+	d.Continue()
+
+	//breakpoints at the same line of main file and included file fire only in their own file
+	var paused []string
+	d.Pause = func(cmd Commander) {
+		paused = append(paused, fmt.Sprintf("%v:%v", d.VM.File, cmd.Line()))
+		d.Continue()
+	}
+	err = d.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"testdata/debug/main.ntf:2", filepath.Join("testdata", "debug", "proc.ntf") + ":2"}, paused)
+	assert.Equal(t, "testdata/debug/main.ntf", d.VM.File)
+	n, _ := d.VM.Var("n")
+	assert.Equal(t, float64(2), n)
+}
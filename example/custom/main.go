@@ -0,0 +1,48 @@
+//A custom neotest binary with an extra command 'sleep <seconds>', e.g.
+//
+//	go build -o neotest ./example/custom
+//	./neotest test.ntf
+package main
+
+import (
+	"fmt"
+	"github.com/hzxiao/neotest"
+	"github.com/hzxiao/neotest/cli"
+	"time"
+)
+
+//SleepCmd 'sleep' command, wait for seconds
+type SleepCmd struct {
+	*neotest.Cmd
+}
+
+func NewSleepCmd(line int) neotest.Commander {
+	return &SleepCmd{
+		Cmd: neotest.NewCmd("sleep", "sleep <seconds>", line),
+	}
+}
+
+func (c *SleepCmd) Exec(vm *neotest.VM) error {
+	v, err := c.RunExprIndexOf(0, vm)
+	if err != nil {
+		return err
+	}
+	seconds, ok := v.(float64)
+	if !ok {
+		return fmt.Errorf("seconds should be a number, but it is %v", v)
+	}
+	time.Sleep(time.Duration(seconds * float64(time.Second)))
+	return nil
+}
+
+func (c *SleepCmd) CheckExpr(varType map[string]string) error {
+	return neotest.CheckExprNumAndType(c.ExprList(), []int{1}, neotest.Float)
+}
+
+func init() {
+	neotest.RegisterCommand("sleep", NewSleepCmd)
+}
+
+func main() {
+	cli.Execute()
+}
@@ -18,6 +18,10 @@ const (
 	Identity
 	SubCommand
 	InternalVar
+	Keyword
+	Array
+	Map
+	Any //only used as type constraint of arguments
 )
 
 func (t ExprType) String() string {
@@ -36,6 +40,14 @@ func (t ExprType) String() string {
 		return "subCmd"
 	case InternalVar:
 		return "internelVar"
+	case Keyword:
+		return "keyword"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	case Any:
+		return "any"
 	}
 
 	return ""
@@ -74,14 +86,24 @@ var _ Variate = new(varExpr)
 var _ Variate = new(boolExpr)
 var _ Variate = new(stringExpr)
 var _ Variate = new(floatExpr)
+var _ Variate = new(arrayExpr)
+var _ Variate = new(mapExpr)
+
+//varRegexp match variable in text, e.g. $(a), $(a.b[0])
+var varRegexp = regexp.MustCompile(`\$\([a-zA-Z_][a-zA-Z0-9_.\[\]]*\)`)
 
 type varExpr struct {
 	exprBackground
 	val string
 }
 
+//varID ID of variable if the expression is exactly a variable, e.g. $(a.b)
+func (expr *varExpr) varID() (string, bool) {
+	return isVar(expr.val)
+}
+
 func (expr *varExpr) Variables() []string {
-	all := regexp.MustCompile(`\$\([a-zA-Z_][a-zA-Z0-9_\\.]*\)`).FindAllString(expr.val, -1)
+	all := varRegexp.FindAllString(expr.val, -1)
 
 	var IDs []string
 	for _, v := range all {
@@ -103,7 +125,7 @@ func (expr *stringExpr) Run(vm *VM) (interface{}, error) {
 	val := expr.val
 
 	//find contain var
-	allIndex := regexp.MustCompile(`\$\([a-zA-Z_][a-zA-Z0-9_\\.]*\)`).FindAllStringIndex(expr.val, -1)
+	allIndex := varRegexp.FindAllStringIndex(expr.val, -1)
 	for _, idx := range allIndex {
 		ID, _ := isVar(expr.val[idx[0]:idx[1]])
 		v, exist := vm.StringV(ID)
@@ -155,6 +177,19 @@ func (expr *IDExpr) Type() ExprType {
 	return Identity
 }
 
+type keywordExpr struct {
+	exprBackground
+	word string
+}
+
+func (expr *keywordExpr) Run(vm *VM) (interface{}, error) {
+	return expr.word, nil
+}
+
+func (expr *keywordExpr) Type() ExprType {
+	return Keyword
+}
+
 type floatExpr struct {
 	varExpr
 }
@@ -177,6 +212,83 @@ func (expr *floatExpr) Type() ExprType {
 	return Float
 }
 
+//arrayExpr array literal whose elements are children, or a variable of array
+type arrayExpr struct {
+	varExpr
+}
+
+func (expr *arrayExpr) Variables() []string {
+	if _, yes := isVar(expr.val); yes {
+		return expr.varExpr.Variables()
+	}
+	return childVariables(expr.children)
+}
+
+func (expr *arrayExpr) Run(vm *VM) (interface{}, error) {
+	ID, yes := isVar(expr.val)
+	if yes {
+		return vm.VarByType(ID, "[]interface {}")
+	}
+
+	values := make([]interface{}, 0, len(expr.children))
+	for _, child := range expr.children {
+		v, err := child.Run(vm)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func (expr *arrayExpr) Type() ExprType {
+	return Array
+}
+
+//mapExpr map literal whose values are children, or a variable of map
+type mapExpr struct {
+	varExpr
+	keys []string
+}
+
+func (expr *mapExpr) Variables() []string {
+	if _, yes := isVar(expr.val); yes {
+		return expr.varExpr.Variables()
+	}
+	return childVariables(expr.children)
+}
+
+func (expr *mapExpr) Run(vm *VM) (interface{}, error) {
+	ID, yes := isVar(expr.val)
+	if yes {
+		return vm.VarByType(ID, "goutil.Map")
+	}
+
+	m := goutil.Map{}
+	for i, child := range expr.children {
+		v, err := child.Run(vm)
+		if err != nil {
+			return nil, err
+		}
+		m.Set(expr.keys[i], v)
+	}
+	return m, nil
+}
+
+func (expr *mapExpr) Type() ExprType {
+	return Map
+}
+
+func childVariables(children []ExprNode) []string {
+	var IDs []string
+	for _, child := range children {
+		if v, ok := child.(Variate); ok {
+			IDs = append(IDs, v.Variables()...)
+		}
+	}
+	return IDs
+}
+
 type internalVarExpr struct {
 	varExpr
 }
@@ -0,0 +1,417 @@
+package neotest
+
+import (
+	"fmt"
+	"github.com/hzxiao/goutil"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var _ Variate = new(arithExpr)
+
+//precedence of binary operators, the bigger binds tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+//arithExpr infix expression wrapped by '((' and '))', e.g. (( $(a) + 1 >= 10 ))
+//it has one operand for unary operator and two for binary operator
+type arithExpr struct {
+	exprBackground
+	op  string
+	typ ExprType
+}
+
+func newArithExpr(op string, typ ExprType, operands ...ExprNode) *arithExpr {
+	expr := &arithExpr{op: op, typ: typ}
+	for _, operand := range operands {
+		operand.SetParent(expr)
+		expr.children = append(expr.children, operand)
+	}
+	return expr
+}
+
+func (expr *arithExpr) Type() ExprType {
+	return expr.typ
+}
+
+func (expr *arithExpr) Variables() []string {
+	return childVariables(expr.children)
+}
+
+func (expr *arithExpr) Run(vm *VM) (interface{}, error) {
+	x, err := expr.children[0].Run(vm)
+	if err != nil {
+		return nil, err
+	}
+	if len(expr.children) == 1 {
+		return unaryOperate(expr.op, x)
+	}
+
+	//short circuit
+	if expr.op == "&&" || expr.op == "||" {
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid operand of %v: %v (type %T)", expr.op, x, x)
+		}
+		if (expr.op == "&&" && !b) || (expr.op == "||" && b) {
+			return b, nil
+		}
+	}
+
+	y, err := expr.children[1].Run(vm)
+	if err != nil {
+		return nil, err
+	}
+	return binaryOperate(expr.op, x, y)
+}
+
+func unaryOperate(op string, x interface{}) (interface{}, error) {
+	switch op {
+	case "!":
+		if b, ok := x.(bool); ok {
+			return !b, nil
+		}
+	case "-":
+		if f, ok := toNumber(x); ok {
+			return -f, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid operand of %v: %v (type %T)", op, x, x)
+}
+
+func binaryOperate(op string, x, y interface{}) (interface{}, error) {
+	fx, xNum := toNumber(x)
+	fy, yNum := toNumber(y)
+	sx, xStr := x.(string)
+	sy, yStr := y.(string)
+
+	switch op {
+	case "&&", "||":
+		if b, ok := y.(bool); ok {
+			return b, nil
+		}
+	case "==":
+		if xNum && yNum {
+			return fx == fy, nil
+		}
+		return reflect.DeepEqual(x, y), nil
+	case "!=":
+		if xNum && yNum {
+			return fx != fy, nil
+		}
+		return !reflect.DeepEqual(x, y), nil
+	case "<", "<=", ">", ">=":
+		if xNum && yNum {
+			return compare(op, fx-fy), nil
+		}
+		if xStr && yStr {
+			return compare(op, float64(strings.Compare(sx, sy))), nil
+		}
+	case "+":
+		if xNum && yNum {
+			return fx + fy, nil
+		}
+		if xStr || yStr {
+			return goutil.String(x) + goutil.String(y), nil
+		}
+	case "-":
+		if xNum && yNum {
+			return fx - fy, nil
+		}
+	case "*":
+		if xNum && yNum {
+			return fx * fy, nil
+		}
+	case "/", "%":
+		if xNum && yNum {
+			if fy == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if op == "/" {
+				return fx / fy, nil
+			}
+			return math.Mod(fx, fy), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid operation: %v %v %v (mismatched types %T and %T)", x, op, y, x, y)
+}
+
+func compare(op string, diff float64) bool {
+	switch op {
+	case "<":
+		return diff < 0
+	case "<=":
+		return diff <= 0
+	case ">":
+		return diff > 0
+	}
+	return diff >= 0
+}
+
+//toNumber convert number value of any go type into float64
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+//arithResultType infer result type of operator on source-parsing stage
+func arithResultType(op string, operands ...ExprType) (ExprType, error) {
+	if op == "==" || op == "!=" {
+		return Bool, nil
+	}
+
+	var dynamic, allNum, anyStr, allBool = false, true, false, true
+	for _, t := range operands {
+		switch t {
+		case InternalVar:
+			dynamic = true
+		case Float:
+			allBool = false
+		case String:
+			allNum, allBool, anyStr = false, false, true
+		case Bool:
+			allNum = false
+		default:
+			return Invalid, fmt.Errorf("invalid operand type %v of %v", t, op)
+		}
+	}
+	mismatch := fmt.Errorf("invalid operation: %v on mismatched types", op)
+
+	switch op {
+	case "!", "&&", "||":
+		if allBool {
+			return Bool, nil
+		}
+	case "<", "<=", ">", ">=":
+		if dynamic || allNum || (anyStr && len(operands) == 2 && operands[0] == operands[1]) {
+			return Bool, nil
+		}
+	case "+":
+		if anyStr && allBoolFree(operands) {
+			return String, nil
+		}
+		if dynamic && allBoolFree(operands) {
+			return InternalVar, nil
+		}
+		if allNum {
+			return Float, nil
+		}
+	default: // - * / %
+		if allNum {
+			return Float, nil
+		}
+	}
+	return Invalid, mismatch
+}
+
+func allBoolFree(types []ExprType) bool {
+	for _, t := range types {
+		if t == Bool {
+			return false
+		}
+	}
+	return true
+}
+
+//arithParser parse tokens of infix expression by precedence climbing
+type arithParser struct {
+	src    *Source
+	tokens []string
+	pos    int
+}
+
+//parseArithExpr parse infix expression, text is the content between '((' and '))'
+func (src *Source) parseArithExpr(text string) (ExprNode, error) {
+	tokens, err := tokenizeArith(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &arithParser{src: src, tokens: tokens}
+	expr, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %v in expression", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func (p *arithParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *arithParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *arithParser) parseBinary(minPrec int) (ExprNode, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.peek()
+		prec, ok := binaryPrecedence[op]
+		if !ok || prec < minPrec {
+			return x, nil
+		}
+		p.next()
+
+		y, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		typ, err := arithResultType(op, operandType(x), operandType(y))
+		if err != nil {
+			return nil, err
+		}
+		x = newArithExpr(op, typ, x, y)
+	}
+}
+
+func (p *arithParser) parseUnary() (ExprNode, error) {
+	switch t := p.next(); t {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "!", "-":
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		typ, err := arithResultType(t, operandType(x))
+		if err != nil {
+			return nil, err
+		}
+		return newArithExpr(t, typ, x), nil
+	case "(":
+		x, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ')' in expression")
+		}
+		return x, nil
+	default:
+		if _, isOp := binaryPrecedence[t]; isOp || t == ")" {
+			return nil, fmt.Errorf("unexpected token %v in expression", t)
+		}
+		return p.src.ParseExpr(t)
+	}
+}
+
+//operandType static type of operand, sub command is typed by its result
+func operandType(expr ExprNode) ExprType {
+	if expr.Type() != SubCommand {
+		return expr.Type()
+	}
+	switch expr.(Resultant).ResultType() {
+	case Bool.String():
+		return Bool
+	case Float.String():
+		return Float
+	case String.String():
+		return String
+	}
+	return InternalVar
+}
+
+//tokenizeArith split infix expression into operators and operands
+func tokenizeArith(text string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(text[i:], "$("):
+			depth := 0
+			j := i + 1
+			for ; j < len(text); j++ {
+				if text[j] == '(' {
+					depth++
+				} else if text[j] == ')' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if j >= len(text) {
+				return nil, fmt.Errorf("unclosed variable in expression")
+			}
+			tokens = append(tokens, text[i:j+1])
+			i = j + 1
+			continue
+		case c == '"' || c == '`':
+			j := strings.IndexByte(text[i+1:], c)
+			if j < 0 {
+				return nil, fmt.Errorf("unclosed %c in expression", c)
+			}
+			tokens = append(tokens, text[i:i+j+2])
+			i += j + 2
+			continue
+		}
+
+		if len(text) > i+1 {
+			if _, ok := binaryPrecedence[text[i:i+2]]; ok {
+				tokens = append(tokens, text[i:i+2])
+				i += 2
+				continue
+			}
+		}
+		if strings.IndexByte("+-*/%<>!()", c) >= 0 {
+			tokens = append(tokens, text[i:i+1])
+			i++
+			continue
+		}
+
+		//number, true or false
+		j := i
+		for j < len(text) && strings.IndexByte(" \t\n\r+-*/%<>!=&|()\"`$", text[j]) < 0 {
+			j++
+		}
+		if j == i {
+			return nil, fmt.Errorf("unexpected character %c in expression", c)
+		}
+		tokens = append(tokens, text[i:j])
+		i = j
+	}
+	return tokens, nil
+}
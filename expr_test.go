@@ -1,6 +1,7 @@
 package neotest
 
 import (
+	"github.com/hzxiao/goutil"
 	"github.com/hzxiao/goutil/assert"
 	"testing"
 )
@@ -44,4 +45,108 @@ func TestStringExpr_Run(t *testing.T) {
 	s5 := newStringExpr("hi,$(e)")
 	_, err = s5.Run(vm)
 	assert.Error(t, err)
-}
\ No newline at end of file
+}
+
+func TestArithExpr_Run(t *testing.T) {
+	src := newSourceByBytes(nil)
+	src.varType["f"] = "float"
+	src.varType["s"] = "string"
+	src.varType["b"] = "bool"
+
+	vm := NewVM(nil)
+	vm.StoreVar("f", 10.0)
+	vm.StoreVar("s", "neo")
+	vm.StoreVar("b", true)
+	vm.StoreVar("resp", goutil.Map{"code": 404})
+
+	var tables = []struct {
+		text   string
+		typ    ExprType
+		result interface{}
+	}{
+		{"1 + 2 * 3", Float, 7.0},
+		{"(1 + 2) * 3", Float, 9.0},
+		{"$(f) - 1", Float, 9.0},
+		{"-$(f) / 4", Float, -2.5},
+		{"$(f) % 3", Float, 1.0},
+		{"10 - 2 - 3", Float, 5.0},
+		{`"a" + $(s) + 1`, String, "aneo1"},
+		{`$(s) == "neo"`, Bool, true},
+		{`$(s) < "zoo"`, Bool, true},
+		{"$(resp.code) >= 400", Bool, true},
+		{"$(resp.code) == 404", Bool, true},
+		{"$(resp.code) + 1", InternalVar, 405.0},
+		{"!$(b) || 1 > 2", Bool, false},
+		{"$(b) && !false", Bool, true},
+		{"false && $(resp.code)", Bool, false},
+	}
+	for _, item := range tables {
+		expr, err := src.parseArithExpr(item.text)
+		assert.NoError(t, err)
+		assert.Equal(t, item.typ, expr.Type())
+
+		result, err := expr.Run(vm)
+		assert.NoError(t, err)
+		assert.Equal(t, item.result, result)
+	}
+
+	var invalid = []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 + 2)",
+		`"a" - 1`,
+		"true + 1",
+		"1 && true",
+		`!"a"`,
+		"$(undefined) + 1",
+		"abc",
+		"1 2",
+	}
+	for _, text := range invalid {
+		_, err := src.parseArithExpr(text)
+		assert.Error(t, err)
+	}
+
+	expr, err := src.parseArithExpr("$(f) / 0")
+	assert.NoError(t, err)
+	_, err = expr.Run(vm)
+	assert.Error(t, err)
+}
+
+func TestArrayAndMapExpr_Run(t *testing.T) {
+	src := newSourceByBytes(nil)
+	src.varType["v"] = "string"
+
+	vm := NewVM(nil)
+	vm.StoreVar("v", "neo")
+
+	arr, err := src.ParseExpr(`[1, "a,b", $(v), [true], {"k": 2}, (( 1 + 2 ))]`)
+	assert.NoError(t, err)
+	assert.Equal(t, Array, arr.Type())
+	assert.Equal(t, []string{"v"}, arr.(Variate).Variables())
+	v, err := arr.Run(vm)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1.0, "a,b", "neo", []interface{}{true}, goutil.Map{"k": 2.0}, 3.0}, v)
+
+	m, err := src.ParseExpr(`{"name": $(v), list: [1, 2], "empty": {}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, Map, m.Type())
+	v, err = m.Run(vm)
+	assert.NoError(t, err)
+	assert.Equal(t, goutil.Map{"name": "neo", "list": []interface{}{1.0, 2.0}, "empty": goutil.Map{}}, v)
+
+	var invalid = []string{
+		"[1,]",
+		"[1, @a]",
+		`[1, $(undefined)]`,
+		`{"k" 1}`,
+		`{"k": 1, "k": 2}`,
+		`{1: 1}`,
+		`["a]`,
+	}
+	for _, text := range invalid {
+		_, err = src.ParseExpr(text)
+		assert.Error(t, err)
+	}
+}
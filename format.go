@@ -0,0 +1,163 @@
+package neotest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const formatIndent = "    "
+
+//formatLine a line of source recorded on parsing, it's a blank line, a comment or a command
+type formatLine struct {
+	text  string
+	depth int
+	cmd   Commander
+}
+
+//Format parse source and re-emit it in canonical layout. Blocks are indented by four spaces,
+//arguments are separated by a single space, comments are kept and runs of blank lines are
+//collapsed into one. Json in string argument of tx-invoke, tx-invokefunc and body is pretty-printed
+func (src *Source) Format() ([]byte, error) {
+	src.recording = true
+	_, err := src.Parse()
+	src.recording = false
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var blank bool
+	for _, l := range src.lines {
+		if l.text == "" {
+			blank = buf.Len() > 0
+			continue
+		}
+		if blank {
+			buf.WriteString("\n")
+			blank = false
+		}
+
+		indent := strings.Repeat(formatIndent, l.depth)
+		if l.cmd == nil {
+			buf.WriteString(indent + l.text + "\n")
+			continue
+		}
+		buf.WriteString(indent + formatCmd(l.cmd, l.text, indent) + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+//record record line for formatting, the delimiter of block is at the same depth as the block
+func (src *Source) record(text string, cmd Commander) {
+	if !src.recording {
+		return
+	}
+	depth := src.depth
+	if _, ok := cmd.(Delimiter); ok && depth > 0 {
+		depth--
+	}
+	src.lines = append(src.lines, &formatLine{text: text, depth: depth, cmd: cmd})
+}
+
+func formatCmd(cmd Commander, text, indent string) string {
+	var tokens []string
+	scan := bufio.NewScanner(strings.NewReader(text))
+	scan.Split(splitExpr)
+	for scan.Scan() {
+		tokens = append(tokens, scan.Text())
+	}
+
+	switch cmd.(type) {
+	case *TxInvokeCmd, *TxInvokeFuncCmd, *BodyCmd:
+		//multi-line string must end the command
+		last := len(tokens) - 1
+		tokens[last] = formatJSONString(tokens[last], indent)
+	}
+	return strings.Join(tokens, " ")
+}
+
+//formatJSONString pretty-print json in single-quoted string, it's returned as it is if it's not json
+func formatJSONString(token, indent string) string {
+	if len(token) < 2 || token[0] != '\'' || token[len(token)-1] != '\'' {
+		return token
+	}
+	masked, vars := maskVars(token[1 : len(token)-1])
+
+	var buf bytes.Buffer
+	err := json.Indent(&buf, []byte(masked), indent, "  ")
+	if err != nil {
+		return token
+	}
+	s := buf.String()
+	for i, v := range vars {
+		placeholder := varPlaceholder(i)
+		if v.bare {
+			placeholder = `"` + placeholder + `"`
+		}
+		s = strings.Replace(s, placeholder, v.text, 1)
+	}
+	return "'" + s + "'"
+}
+
+type maskedVar struct {
+	text string
+	bare bool //not in json string
+}
+
+//maskVars replace variables in json with placeholders, a variable out of json string is quoted
+//so that the json is valid
+func maskVars(s string) (string, []maskedVar) {
+	var buf strings.Builder
+	var vars []maskedVar
+	var inString bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString && c == '\\' && i+1 < len(s):
+			buf.WriteString(s[i : i+2])
+			i++
+			continue
+		case c == '"':
+			inString = !inString
+		case c == '$' && strings.HasPrefix(s[i:], "$("):
+			end := matchParen(s, i+1)
+			if end < 0 {
+				break
+			}
+			placeholder := varPlaceholder(len(vars))
+			vars = append(vars, maskedVar{text: s[i : end+1], bare: !inString})
+			if !inString {
+				placeholder = `"` + placeholder + `"`
+			}
+			buf.WriteString(placeholder)
+			i = end
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String(), vars
+}
+
+//matchParen index of the parenthesis matching the one at start, -1 if not found
+func matchParen(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func varPlaceholder(i int) string {
+	return fmt.Sprintf("__ntf_var_%v__", i)
+}
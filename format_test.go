@@ -0,0 +1,34 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil/assert"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSource_Format(t *testing.T) {
+	src, err := NewSource("testdata/fmt/messy.ntf")
+	assert.NoError(t, err)
+	formatted, err := src.Format()
+	assert.NoError(t, err)
+	golden, err := ioutil.ReadFile("testdata/fmt/messy.golden")
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), string(formatted))
+
+	//formatting is idempotent
+	src = newSourceByBytes(formatted)
+	again, err := src.Format()
+	assert.NoError(t, err)
+	assert.Equal(t, string(formatted), string(again))
+
+	_, err = newSourceByBytes([]byte("foo 1")).Format()
+	assert.Error(t, err)
+}
+
+func TestFormatJSONString(t *testing.T) {
+	assert.Equal(t, "'{\n  \"a\": $(a),\n  \"b\": \"x $(b.c) y\"\n}'", formatJSONString(`'{"a":$(a),"b":"x $(b.c) y"}'`, ""))
+	assert.Equal(t, "'[\n      1\n    ]'", formatJSONString("'[1]'", "    "))
+	//not json
+	assert.Equal(t, "'abc'", formatJSONString("'abc'", ""))
+	assert.Equal(t, `"{}"`, formatJSONString(`"{}"`, ""))
+}
@@ -0,0 +1,79 @@
+package neo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/hzxiao/goutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	//FreeGas gas of every invocation which is free of charge
+	FreeGas = 10
+	//MaxFreeTxSize max size of tx which is free of network fee
+	MaxFreeTxSize = 1024
+	//MinNetworkFee network fee of tx larger than MaxFreeTxSize, besides fee of the extra bytes. It's 0.001 gas
+	MinNetworkFee util.Fixed8 = 100000
+	//FeePerExtraByte network fee of every byte beyond MaxFreeTxSize. It's 0.00001 gas
+	FeePerExtraByte util.Fixed8 = 1000
+)
+
+//InvokeScript run script by node without sending tx, the result has state, gas_consumed and stack
+func InvokeScript(client *Client, script []byte) (goutil.Map, error) {
+	var res goutil.Map
+	err := client.Call("invokescript", []string{hex.EncodeToString(script)}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+//EstimateSysFee system fee required by gas consumed, the free gas is deducted and the fee is rounded up to integer
+func EstimateSysFee(gasConsumed float64) float64 {
+	fee := gasConsumed - FreeGas
+	if fee <= 0 {
+		return 0
+	}
+	return math.Ceil(fee)
+}
+
+//EstimateNetFee network fee required by size of tx, it's computed in fixed8 to be exact
+func EstimateNetFee(size int) util.Fixed8 {
+	if size <= MaxFreeTxSize {
+		return 0
+	}
+	return MinNetworkFee + util.Fixed8(size-MaxFreeTxSize)*FeePerExtraByte
+}
+
+//estimateSysFee set system fee by test invocation of script
+func (tx *Tx) estimateSysFee(client *Client) error {
+	if len(tx.Param.Script) == 0 {
+		return fmt.Errorf("system fee is only for invocation tx with script")
+	}
+	res, err := InvokeScript(client, tx.Param.Script)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(res.GetString("state"), "FAULT") {
+		return fmt.Errorf("test invocation fails with state %v", res.GetString("state"))
+	}
+	consumed, err := GasConsumed(res)
+	if err != nil {
+		return err
+	}
+	return tx.SetSysFee(EstimateSysFee(consumed))
+}
+
+//GasConsumed gas_consumed of invocation result, it's a decimal string in result of node
+func GasConsumed(res goutil.Map) (float64, error) {
+	switch v := res.Get("gas_consumed").(type) {
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("invalid gas_consumed: %v", res.Get("gas_consumed"))
+}
@@ -0,0 +1,180 @@
+package neo
+
+import (
+	"encoding/hex"
+	"github.com/CityOfZion/neo-go/pkg/crypto"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/hzxiao/goutil"
+	"math/big"
+	"strconv"
+)
+
+//InvokeTest run script by node as a dry run, the result has state, gas_consumed and the decoded stack
+func InvokeTest(client *Client, script []byte) (goutil.Map, error) {
+	res, err := InvokeScript(client, script)
+	if err != nil {
+		return nil, err
+	}
+	consumed, err := GasConsumed(res)
+	if err != nil {
+		return nil, err
+	}
+	stack, _ := res.Get("stack").([]interface{})
+	return goutil.Map{
+		"script":       hex.EncodeToString(script),
+		"state":        res.GetString("state"),
+		"gas_consumed": consumed,
+		"stack":        DecodeStack(stack),
+	}, nil
+}
+
+//DecodeStack decode items of stack returned by invocation
+func DecodeStack(stack []interface{}) []interface{} {
+	items := make([]interface{}, 0, len(stack))
+	for _, item := range stack {
+		items = append(items, DecodeStackItem(goutil.MapV(item)))
+	}
+	return items
+}
+
+//DecodeStackItem decode value of stack item by its type. Integer is decoded to number, Boolean to bool,
+//Array to decoded items, and ByteArray is kept as hex with its string and integer forms. The exact decimal
+//string of integer is in integer_str since number may lose precision
+func DecodeStackItem(item goutil.Map) goutil.Map {
+	typ := item.GetString("type")
+	decoded := goutil.Map{"type": typ, "value": item.Get("value")}
+	switch typ {
+	case "Integer":
+		n, ok := new(big.Int).SetString(goutil.String(item.Get("value")), 10)
+		if !ok {
+			break
+		}
+		f, _ := new(big.Float).SetInt(n).Float64()
+		decoded.Set("value", f)
+		decoded.Set("integer_str", n.String())
+	case "Boolean":
+		switch v := item.Get("value").(type) {
+		case bool:
+			decoded.Set("value", v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				decoded.Set("value", b)
+			}
+		}
+	case "Array", "Struct":
+		values, _ := item.Get("value").([]interface{})
+		decoded.Set("value", DecodeStack(values))
+	case "ByteArray":
+		bs, err := hex.DecodeString(item.GetString("value"))
+		if err != nil {
+			break
+		}
+		decoded.Set("hex", item.GetString("value"))
+		decoded.Set("string", string(bs))
+		n := BytesToBigInt(bs)
+		f, _ := new(big.Float).SetInt(n).Float64()
+		decoded.Set("integer", f)
+		decoded.Set("integer_str", n.String())
+	}
+	return decoded
+}
+
+//BytesToInteger integer of bytes in little-endian two's complement, which is the integer format of neo vm.
+//It may lose precision beyond 2^53, see BytesToBigInt for the exact value
+func BytesToInteger(bs []byte) float64 {
+	f, _ := new(big.Float).SetInt(BytesToBigInt(bs)).Float64()
+	return f
+}
+
+//BytesToBigInt exact integer of bytes in little-endian two's complement
+func BytesToBigInt(bs []byte) *big.Int {
+	if len(bs) == 0 {
+		return new(big.Int)
+	}
+	be := make([]byte, len(bs))
+	for i, b := range bs {
+		be[len(bs)-1-i] = b
+	}
+	n := new(big.Int).SetBytes(be)
+	if be[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(bs)*8)))
+	}
+	return n
+}
+
+//GetApplicationLog log of application execution of tx, the result has vmstate, gas_consumed, the decoded stack
+//and notifications
+func GetApplicationLog(client *Client, txid string) (goutil.Map, error) {
+	var res goutil.Map
+	err := client.Call("getapplicationlog", []string{txid}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	//logs of new nodes are grouped by trigger in executions
+	execution := res
+	if executions, ok := res.Get("executions").([]interface{}); ok && len(executions) > 0 {
+		execution = goutil.MapV(executions[0])
+		for _, item := range executions {
+			if goutil.MapV(item).GetString("trigger") == "Application" {
+				execution = goutil.MapV(item)
+				break
+			}
+		}
+	}
+
+	consumed, err := GasConsumed(execution)
+	if err != nil {
+		return nil, err
+	}
+	stack, _ := execution.Get("stack").([]interface{})
+	notifications, _ := execution.Get("notifications").([]interface{})
+	var decoded []interface{}
+	for _, n := range notifications {
+		decoded = append(decoded, DecodeNotification(goutil.MapV(n)))
+	}
+	return goutil.Map{
+		"txid":          txid,
+		"vmstate":       execution.GetString("vmstate"),
+		"gas_consumed":  consumed,
+		"stack":         DecodeStack(stack),
+		"notifications": decoded,
+	}, nil
+}
+
+//DecodeNotification decode state of notification, the first item is the event name. For NEP-5 transfer, from, to
+//and amount are decoded as well, the addresses are empty on minting or burning, and amount is the exact decimal
+//string of integer
+func DecodeNotification(n goutil.Map) goutil.Map {
+	decoded := goutil.Map{"contract": n.GetString("contract")}
+	state := DecodeStackItem(goutil.MapV(n.Get("state")))
+	items, _ := state.Get("value").([]interface{})
+	decoded.Set("state", items)
+	if len(items) == 0 {
+		return decoded
+	}
+	event := goutil.MapV(items[0]).GetString("string")
+	decoded.Set("event", event)
+	if event != "transfer" || len(items) != 4 {
+		return decoded
+	}
+
+	decoded.Set("from", stackItemAddress(goutil.MapV(items[1])))
+	decoded.Set("to", stackItemAddress(goutil.MapV(items[2])))
+	decoded.Set("amount", goutil.MapV(items[3]).GetString("integer_str"))
+	return decoded
+}
+
+//stackItemAddress address of script hash in ByteArray item, it's empty if the item is not a script hash
+func stackItemAddress(item goutil.Map) string {
+	bs, err := hex.DecodeString(item.GetString("hex"))
+	if err != nil {
+		return ""
+	}
+	hash, err := util.Uint160DecodeBytes(bs)
+	if err != nil {
+		return ""
+	}
+	return crypto.AddressFromUint160(hash)
+}
@@ -0,0 +1,138 @@
+package neo
+
+import (
+	"github.com/hzxiao/goutil"
+	"github.com/hzxiao/goutil/assert"
+	"testing"
+)
+
+func TestBytesToInteger(t *testing.T) {
+	assert.Equal(t, 0.0, BytesToInteger(nil))
+	assert.Equal(t, 1.0, BytesToInteger([]byte{0x01}))
+	assert.Equal(t, 256.0, BytesToInteger([]byte{0x00, 0x01}))
+	assert.Equal(t, 128.0, BytesToInteger([]byte{0x80, 0x00}))
+	assert.Equal(t, -1.0, BytesToInteger([]byte{0xff}))
+	assert.Equal(t, 1e8, BytesToInteger([]byte{0x00, 0xe1, 0xf5, 0x05}))
+
+	//exact beyond 2^53
+	assert.Equal(t, "9007199254740993", BytesToBigInt([]byte{0x01, 0, 0, 0, 0, 0, 0x20, 0}).String())
+	assert.Equal(t, "-1", BytesToBigInt([]byte{0xff}).String())
+	item := DecodeStackItem(goutil.Map{"type": "ByteArray", "value": "0100000000002000"})
+	assert.Equal(t, "9007199254740993", item.Get("integer_str"))
+	item = DecodeStackItem(goutil.Map{"type": "Integer", "value": "9007199254740993"})
+	assert.Equal(t, "9007199254740993", item.Get("integer_str"))
+	assert.Equal(t, 9007199254740992.0, item.Get("value"))
+}
+
+func TestInvokeTest(t *testing.T) {
+	node := newTestNode(t, map[string]interface{}{
+		"invokescript": map[string]interface{}{
+			"state":        "HALT, BREAK",
+			"gas_consumed": "0.338",
+			"stack": []interface{}{
+				map[string]interface{}{"type": "ByteArray", "value": "00e1f505"},
+				map[string]interface{}{"type": "ByteArray", "value": "4e4550"},
+				map[string]interface{}{"type": "Integer", "value": "8"},
+				map[string]interface{}{"type": "Boolean", "value": "true"},
+				map[string]interface{}{"type": "Array", "value": []interface{}{
+					map[string]interface{}{"type": "Boolean", "value": false},
+				}},
+			},
+		},
+	})
+	defer node.Close()
+
+	res, err := InvokeTest(NewClient(node.URL), []byte{0x51})
+	assert.NoError(t, err)
+	assert.Equal(t, "HALT, BREAK", res.GetString("state"))
+	assert.Equal(t, 0.338, res.GetFloat64("gas_consumed"))
+	assert.Equal(t, "51", res.GetString("script"))
+
+	stack, _ := res.Get("stack").([]interface{})
+	assert.Equal(t, 5, len(stack))
+	item := goutil.MapV(stack[0])
+	assert.Equal(t, "00e1f505", item.GetString("value"))
+	assert.Equal(t, 1e8, item.GetFloat64("integer"))
+	assert.Equal(t, "NEP", goutil.MapV(stack[1]).GetString("string"))
+	assert.Equal(t, 8.0, goutil.MapV(stack[2]).Get("value"))
+	assert.Equal(t, true, goutil.MapV(stack[3]).Get("value"))
+	array, _ := goutil.MapV(stack[4]).Get("value").([]interface{})
+	assert.Equal(t, 1, len(array))
+	assert.Equal(t, false, goutil.MapV(array[0]).Get("value"))
+
+	_, err = InvokeTest(NewClient("http://127.0.0.1:1"), []byte{0x51})
+	assert.Error(t, err)
+}
+
+func TestGetApplicationLog(t *testing.T) {
+	transfer := map[string]interface{}{
+		"contract": "0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9",
+		"state": map[string]interface{}{"type": "Array", "value": []interface{}{
+			map[string]interface{}{"type": "ByteArray", "value": "7472616e73666572"},
+			map[string]interface{}{"type": "ByteArray", "value": ""},
+			map[string]interface{}{"type": "ByteArray", "value": "5e40b22e86dc6ff4a7b0416450971469fe71040d"},
+			map[string]interface{}{"type": "ByteArray", "value": "00e1f505"},
+		}},
+	}
+	results := map[string]interface{}{
+		"getapplicationlog": map[string]interface{}{
+			"txid": "0x4ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cff",
+			"executions": []interface{}{
+				map[string]interface{}{"trigger": "Verification", "vmstate": "HALT, BREAK", "gas_consumed": "0"},
+				map[string]interface{}{
+					"trigger":       "Application",
+					"vmstate":       "HALT, BREAK",
+					"gas_consumed":  "2.855",
+					"stack":         []interface{}{map[string]interface{}{"type": "Integer", "value": "1"}},
+					"notifications": []interface{}{transfer},
+				},
+			},
+		},
+	}
+	node := newTestNode(t, results)
+	defer node.Close()
+
+	applog, err := GetApplicationLog(NewClient(node.URL), "4ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cff")
+	assert.NoError(t, err)
+	assert.Equal(t, "HALT, BREAK", applog.GetString("vmstate"))
+	assert.Equal(t, 2.855, applog.GetFloat64("gas_consumed"))
+	notifications, _ := applog.Get("notifications").([]interface{})
+	assert.Equal(t, 1, len(notifications))
+	n := goutil.MapV(notifications[0])
+	assert.Equal(t, "transfer", n.GetString("event"))
+	assert.Equal(t, "", n.GetString("from"))
+	assert.True(t, len(n.GetString("to")) > 0)
+	assert.Equal(t, "100000000", n.Get("amount"))
+
+	//log of old nodes is not grouped by trigger
+	results["getapplicationlog"] = map[string]interface{}{
+		"vmstate":       "FAULT, BREAK",
+		"gas_consumed":  "0.1",
+		"notifications": []interface{}{},
+	}
+	applog, err = GetApplicationLog(NewClient(node.URL), "4ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cff")
+	assert.NoError(t, err)
+	assert.Equal(t, "FAULT, BREAK", applog.GetString("vmstate"))
+	notifications, _ = applog.Get("notifications").([]interface{})
+	assert.Equal(t, 0, len(notifications))
+}
+
+func TestGetAssetDecimals_Observer(t *testing.T) {
+	node := newTestNode(t, map[string]interface{}{
+		"invokefunction": map[string]interface{}{
+			"state": "HALT, BREAK",
+			"stack": []interface{}{map[string]interface{}{"type": "Integer", "value": "8"}},
+		},
+	})
+	defer node.Close()
+
+	var methods []string
+	client := NewClient(node.URL)
+	client.Observer = func(url, method string, params, result interface{}, err error) {
+		methods = append(methods, method)
+	}
+	decimals, err := getAssetDecimals(client, "0xecc6b20d3ccac1ee9ef109af5a7cdb85706b1df9")
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(8), decimals)
+	assert.Equal(t, []string{"invokefunction"}, methods)
+}
@@ -1,10 +1,10 @@
 package neo
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/CityOfZion/neo-go/pkg/core/transaction"
 	"github.com/CityOfZion/neo-go/pkg/util"
-	"github.com/OTCGO/sea-server-go/pkg/neo"
 	"github.com/hzxiao/goutil"
 	"github.com/hzxiao/neotest/pkg/jsonrpc2"
 	"sort"
@@ -17,7 +17,22 @@ const (
 	NeoAssetHash = `c56f33fc6ecfcd0c225c4ab356fee59390af8560be0e930faebe74a6daff7c9b`
 )
 
-func Rpc(url, method string, params interface{}, result interface{}) error {
+//RpcObserver is called after every rpc request, e.g. to record the exchanges for report
+type RpcObserver func(url, method string, params, result interface{}, err error)
+
+//Client rpc client of a node, it's held by caller so that every caller has its own observer
+type Client struct {
+	URL string
+	//Observer is called after every request if it's set
+	Observer RpcObserver
+}
+
+func NewClient(url string) *Client {
+	return &Client{URL: url}
+}
+
+//Call send rpc request of method to node
+func (c *Client) Call(method string, params interface{}, result interface{}) error {
 	r := &jsonrpc2.JRpcRequest{
 		ID:     1,
 		Method: method,
@@ -27,7 +42,16 @@ func Rpc(url, method string, params interface{}, result interface{}) error {
 		return err
 	}
 
-	return jsonrpc2.Send(url, r, &result)
+	err = jsonrpc2.Send(c.URL, r, &result)
+	if c.Observer != nil {
+		c.Observer(c.URL, method, params, result, err)
+	}
+	return err
+}
+
+//Rpc send rpc request to node without observer
+func Rpc(url, method string, params interface{}, result interface{}) error {
+	return NewClient(url).Call(method, params, result)
 }
 
 type References []goutil.Map
@@ -36,10 +60,10 @@ func (a References) Len() int           { return len(a) }
 func (a References) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a References) Less(i, j int) bool { return a[i].GetFloat64("value") < a[j].GetFloat64("value") }
 
-//getReference get unspent asset as input equal or large than given value
-func getReference(asset, address string, value float64, node string) (float64, []*transaction.Input, error){
+//getReference get unspent asset as input equal or large than given value, the used inputs are skipped
+func getReference(asset, address string, value float64, client *Client, used []*transaction.Input) (float64, []*transaction.Input, error) {
 	var res goutil.Map
-	err := Rpc(node, "getunspents", []string{address}, &res)
+	err := client.Call("getunspents", []string{address}, &res)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -56,12 +80,16 @@ func getReference(asset, address string, value float64, node string) (float64, [
 		unspent := balance.GetMapArray("unspent")
 		sort.Sort(References(unspent))
 		for _, ref := range unspent {
-			all += ref.GetFloat64("value")
 			hash, _ := util.Uint256DecodeString(ref.GetString("txid"))
-			reference = append(reference, &transaction.Input{
+			input := &transaction.Input{
 				PrevHash: hash,
 				PrevIndex: uint16(ref.GetInt64("n")),
-			})
+			}
+			if containsInput(used, input) {
+				continue
+			}
+			all += ref.GetFloat64("value")
+			reference = append(reference, input)
 
 			if all > value {
 				return all, reference, nil
@@ -75,8 +103,53 @@ func getReference(asset, address string, value float64, node string) (float64, [
 	return all, reference, nil
 }
 
+//getClaimable get the outputs whose gas is claimable and the total claimable amount of address. The amount is
+//the unclaimed of the same response, and it's decoded as fixed8 since it is decimal of gas
+func getClaimable(address string, client *Client) ([]*transaction.Input, util.Fixed8, error) {
+	var res struct {
+		Claimable []goutil.Map `json:"claimable"`
+		Unclaimed json.Number  `json:"unclaimed"`
+	}
+	err := client.Call("getclaimable", []string{address}, &res)
+	if err != nil {
+		return nil, 0, err
+	}
+	amount, err := util.Fixed8DecodeString(res.Unclaimed.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid unclaimed gas %v: %v", res.Unclaimed, err)
+	}
+	if amount <= 0 {
+		return nil, 0, fmt.Errorf("no claimable gas of %v", address)
+	}
+
+	var claims []*transaction.Input
+	for _, ref := range res.Claimable {
+		hash, err := util.Uint256DecodeString(strings.TrimPrefix(ref.GetString("txid"), "0x"))
+		if err != nil {
+			return nil, 0, err
+		}
+		claims = append(claims, &transaction.Input{
+			PrevHash:  hash,
+			PrevIndex: uint16(ref.GetInt64("n")),
+		})
+	}
+	if len(claims) == 0 {
+		return nil, 0, fmt.Errorf("no claimable output of %v", address)
+	}
+	return claims, amount, nil
+}
+
+func containsInput(inputs []*transaction.Input, input *transaction.Input) bool {
+	for _, in := range inputs {
+		if in.PrevHash == input.PrevHash && in.PrevIndex == input.PrevIndex {
+			return true
+		}
+	}
+	return false
+}
+
 //getAssetDecimals get asset decimals
-func getAssetDecimals(node, asset string) (uint8, error) {
+func getAssetDecimals(client *Client, asset string) (uint8, error) {
 	asset = strings.TrimPrefix(asset, "0x")
 	switch asset {
 	case GasAssetHash:
@@ -86,23 +159,23 @@ func getAssetDecimals(node, asset string) (uint8, error) {
 	}
 
 	if IsGlobalAsset(asset) {
-		return getGlobalAssetDecimals(node, asset)
+		return getGlobalAssetDecimals(client, asset)
 	} else {
-		return getNep5AssetDecimals(node, asset)
+		return getNep5AssetDecimals(client, asset)
 	}
 }
 
-func getGlobalAssetDecimals(node string, asset string) (uint8, error) {
+func getGlobalAssetDecimals(client *Client, asset string) (uint8, error) {
 	var res goutil.Map
-	err := Rpc(node, "getassetstate", []string{asset}, &res)
+	err := client.Call("getassetstate", []string{asset}, &res)
 	if err != nil {
 		return 0, err
 	}
 	return uint8(res.GetInt64("precision")), nil
 }
 
-func getNep5AssetDecimals(node string, contract string) (uint8, error) {
-	v, success, err := rpcInvoke(node, []string{contract, "decimals"})
+func getNep5AssetDecimals(client *Client, contract string) (uint8, error) {
+	v, success, err := rpcInvoke(client, []string{contract, "decimals"})
 	if err != nil {
 		return 0, fmt.Errorf("rpc invoke func(%v) fail(%v)", "decimals", err)
 	}
@@ -113,7 +186,7 @@ func getNep5AssetDecimals(node string, contract string) (uint8, error) {
 	return uint8(d), nil
 }
 
-func rpcInvoke(node string, params interface{}) (goutil.Map, bool, error) {
+func rpcInvoke(client *Client, params interface{}) (goutil.Map, bool, error) {
 	var invokeFail = func(r goutil.Map) bool {
 		if r == nil {
 			return false
@@ -122,7 +195,7 @@ func rpcInvoke(node string, params interface{}) (goutil.Map, bool, error) {
 	}
 
 	var result = goutil.Map{}
-	err := neo.Rpc(node, "invokefunction", params, &result)
+	err := client.Call("invokefunction", params, &result)
 	if err != nil {
 		return nil, false, err
 	}
@@ -16,12 +16,17 @@ import (
 )
 
 type TxParam struct {
-	Fee       util.Fixed8
-	Attr      []goutil.Map
-	Initiator *wallet.PrivateKey
-	Vout      []goutil.Map
-	Script    []byte
-	Witness   []goutil.Map
+	Fee        util.Fixed8
+	SysFee     util.Fixed8 //gas of invocation tx
+	AutoFee    bool        //network fee is estimated by size of tx
+	AutoSysFee bool        //system fee is estimated by test invocation
+	Attr       []goutil.Map
+	Initiator  *wallet.PrivateKey
+	Vout       []goutil.Map
+	Script     []byte
+	Witness    []goutil.Map
+	Claim      bool   //claim the unclaimed gas of initiator
+	ClaimTo    string //address which receives the claimed gas, it's initiator if empty
 }
 
 func (p *TxParam) SetInitiator(initiator string) error {
@@ -44,6 +49,7 @@ func NewTx(name string) *Tx {
 	return &Tx{Transaction: transaction.Transaction{}, Name: name, Param: &TxParam{}}
 }
 
+//Label name of tx, or hash if it's unnamed. The hash is cached once computed, so it's only called after completing
 func (tx *Tx) Label() string {
 	if tx.Name != "" {
 		return tx.Name
@@ -57,6 +63,8 @@ func (tx *Tx) SetType(typ string) error {
 		tx.Type = transaction.ContractType
 	case "invocation":
 		tx.Type = transaction.InvocationType
+	case "claim":
+		tx.Type = transaction.ClaimType
 	default:
 		return fmt.Errorf("unsupport tx type(%v)", typ)
 	}
@@ -64,8 +72,29 @@ func (tx *Tx) SetType(typ string) error {
 	return nil
 }
 
-func (tx *Tx) SetFee(fee float64) {
-	tx.Param.Fee = Fixed8FromFloat64(fee)
+func (tx *Tx) SetFee(fee float64) error {
+	f, err := Fixed8FromFloat64(fee)
+	if err != nil {
+		return err
+	}
+	tx.Param.Fee = f
+	return nil
+}
+
+//SetSysFee set system fee which is the gas of invocation tx, it must be a whole number of gas
+func (tx *Tx) SetSysFee(fee float64) error {
+	if fee < 0 {
+		return fmt.Errorf("system fee must not be negative")
+	}
+	if fee != math.Floor(fee) {
+		return fmt.Errorf("system fee must be a whole number of gas, but it is %v", fee)
+	}
+	f, err := Fixed8FromFloat64(fee)
+	if err != nil {
+		return err
+	}
+	tx.Param.SysFee = f
+	return nil
 }
 
 func (tx *Tx) ParseInvoke(raw string) error {
@@ -141,11 +170,45 @@ func (tx *Tx) ParseInvokeFunc(raw string) error {
 	return nil
 }
 
-func (tx *Tx) Complete(node string) error {
+//Complete select inputs, build outputs and data, and sign the tx. The auto fees are
+//estimated before selecting inputs
+func (tx *Tx) Complete(client *Client) error {
 	param := tx.Param
 	if param == nil {
 		return fmt.Errorf("tx param is nil")
 	}
+	if param.AutoSysFee {
+		err := tx.estimateSysFee(client)
+		if err != nil {
+			return err
+		}
+	}
+	if !param.AutoFee {
+		return tx.complete(client)
+	}
+
+	//network fee depends on size which depends on the inputs paying fee, so complete again until the fee is enough
+	base := tx.Transaction
+	param.Fee = 0
+	for i := 0; i < maxFeeEstimation; i++ {
+		tx.Transaction = base
+		err := tx.complete(client)
+		if err != nil {
+			return err
+		}
+		fee := EstimateNetFee(tx.Size())
+		if fee <= param.Fee {
+			return nil
+		}
+		param.Fee = fee
+	}
+	return fmt.Errorf("network fee is not estimated after %v tries", maxFeeEstimation)
+}
+
+const maxFeeEstimation = 5
+
+func (tx *Tx) complete(client *Client) error {
+	param := tx.Param
 	if param.Initiator == nil {
 		return fmt.Errorf("initiator is emptty")
 	}
@@ -154,16 +217,19 @@ func (tx *Tx) Complete(node string) error {
 	if err != nil {
 		return err
 	}
-	//fee
-	if param.Fee > 0 {
-		fee, _ := strconv.ParseFloat(param.Fee.String(), 64)
-		all, inputs, err := getReference(GasAssetHash, address, fee, node)
+	//network fee and system fee are paid by gas of initiator together
+	if param.SysFee > 0 && len(param.Script) == 0 {
+		return fmt.Errorf("system fee is only for invocation tx with script")
+	}
+	if fees := param.Fee + param.SysFee; fees > 0 {
+		fee := Fixed8ToFloat64(fees)
+		all, inputs, err := getReference(GasAssetHash, address, fee, client, tx.Inputs)
 		if err != nil {
 			return err
 		}
 		tx.Inputs = append(tx.Inputs, inputs...)
-		if all > fee { //redundant
-			redundant := util.Fixed8(Fixed8FromFloat64(all) - Fixed8FromFloat64(fee))
+		//all is sum of float values, so it's rounded to fixed8 instead of being decoded
+		if redundant := util.Fixed8(math.Round(all*1e8)) - fees; redundant > 0 {
 			asset, _ := util.Uint256DecodeString(GasAssetHash)
 			scripthash, _ := crypto.Uint160DecodeAddress(address)
 			tx.Outputs = append(tx.Outputs, transaction.NewOutput(asset, redundant, scripthash))
@@ -176,12 +242,12 @@ func (tx *Tx) Complete(node string) error {
 		if value <= 0 {
 			continue
 		}
-		all, inputs, err := getReference(out.GetString("asset"), address, value, node)
+		all, inputs, err := getReference(out.GetString("asset"), address, value, client, tx.Inputs)
 		if err != nil {
 			return err
 		}
 		tx.Inputs = append(tx.Inputs, inputs...)
-		d, err := getAssetDecimals(node, out.GetString("asset"))
+		d, err := getAssetDecimals(client, out.GetString("asset"))
 		if err != nil {
 			return err
 		}
@@ -204,9 +270,22 @@ func (tx *Tx) Complete(node string) error {
 		}
 	}
 
+	//claim
+	if param.Claim {
+		if tx.Type != transaction.ClaimType {
+			return fmt.Errorf("wrong tx type, should be claim")
+		}
+		err = tx.completeClaim(address, client)
+		if err != nil {
+			return err
+		}
+	} else if tx.Type == transaction.ClaimType {
+		return fmt.Errorf("claim tx without tx-claim")
+	}
+
 	//invoke script
 	if len(param.Script) > 0 {
-		tx.Data = &transaction.InvocationTX{Script: param.Script}
+		tx.Data = &transaction.InvocationTX{Script: param.Script, Gas: param.SysFee}
 		if tx.Type != transaction.InvocationType {
 			return fmt.Errorf("wrong tx type, should be invocation")
 		}
@@ -267,7 +346,37 @@ func (tx *Tx) Complete(node string) error {
 	return nil
 }
 
+//completeClaim claim the claimable gas of address, the claimed gas is output to param.ClaimTo or address
+func (tx *Tx) completeClaim(address string, client *Client) error {
+	claims, amount, err := getClaimable(address, client)
+	if err != nil {
+		return err
+	}
+	to := tx.Param.ClaimTo
+	if to == "" {
+		to = address
+	}
+	scripthash, err := crypto.Uint160DecodeAddress(to)
+	if err != nil {
+		return err
+	}
+	asset, _ := util.Uint256DecodeString(GasAssetHash)
+
+	tx.Data = &transaction.ClaimTX{Claims: claims}
+	tx.Outputs = append(tx.Outputs, transaction.NewOutput(asset, amount, scripthash))
+	return nil
+}
+
 func (tx *Tx) ToMap() goutil.Map {
+	m := tx.toMap()
+	if m != nil {
+		m.Set("txid", tx.Hash())
+	}
+	return m
+}
+
+//toMap map of tx without txid, the hash is cached once it's computed so that it must not be computed before completing
+func (tx *Tx) toMap() goutil.Map {
 	m := goutil.Struct2Map(tx)
 	if m == nil {
 		return m
@@ -280,14 +389,37 @@ func (tx *Tx) ToMap() goutil.Map {
 		inv, ok := tx.Data.(*transaction.InvocationTX)
 		if ok && inv != nil {
 			script = hex.EncodeToString(inv.Script)
-			fee = float64(inv.Gas) / 8
+			fee = Fixed8ToFloat64(inv.Gas)
 		}
 		m.Set("script", script)
 		m.Set("gas", fee)
 	}
-	m.Set("txid", tx.Hash())
 	m.Set("size", tx.Size())
-	m.Set("net_fee", strconv.FormatFloat(float64(tx.Param.Fee)/8, 'f', -1, 64))
+	m.Set("net_fee", strconv.FormatFloat(Fixed8ToFloat64(tx.Param.Fee), 'f', -1, 64))
+	return m
+}
+
+//PendingMap map of tx with the parameters which are set before completing, the initiator is shown as address.
+//There is no txid since tx is not completed
+func (tx *Tx) PendingMap() goutil.Map {
+	m := tx.toMap()
+	if m == nil {
+		m = goutil.Map{}
+	}
+	m.Set("name", tx.Name)
+	var initiator string
+	if tx.Param.Initiator != nil {
+		initiator, _ = tx.Param.Initiator.Address()
+	}
+	m.Set("param", goutil.Map{
+		"attr":      tx.Param.Attr,
+		"initiator": initiator,
+		"vout":      tx.Param.Vout,
+		"script":    hex.EncodeToString(tx.Param.Script),
+		"witness":   tx.Param.Witness,
+		"claim":     tx.Param.Claim,
+		"claim_to":  tx.Param.ClaimTo,
+	})
 	return m
 }
 
@@ -342,23 +474,31 @@ func (tx *Tx) EncodeHashableFields() ([]byte, error) {
 	return w.Bytes(), nil
 }
 
+//RawHex hex string of serialized tx
+func (tx *Tx) RawHex() (string, error) {
+	tx.Hash()
+
+	w := new(bytes.Buffer)
+	err := tx.EncodeBinary(w)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(w.Bytes()), nil
+}
+
 // RelayTx relay tx to the neo node
-func RelayTx(tx *Tx, node string) error {
+func RelayTx(tx *Tx, client *Client) error {
 	if tx == nil {
 		return fmt.Errorf("tx is nil")
 	}
 
-	tx.Hash()
-
-	w := new(bytes.Buffer)
-	err := tx.EncodeBinary(w)
+	raw, err := tx.RawHex()
 	if err != nil {
 		return err
 	}
 
-	raw := hex.EncodeToString(w.Bytes())
 	var res bool
-	err = Rpc(node, "sendrawtransaction", []string{raw}, &res)
+	err = client.Call("sendrawtransaction", []string{raw}, &res)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,222 @@
+package neo
+
+import (
+	"encoding/json"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
+	"github.com/CityOfZion/neo-go/pkg/util"
+	"github.com/hzxiao/goutil"
+	"github.com/hzxiao/goutil/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const testPrivateKey = "6695b463341ff2edc95c7ece6e15b683b0c23d9858d9bf69b699e1757f1ecd3a"
+
+//newTestNode fake node which responses rpc by results of methods
+func newTestNode(t *testing.T, results map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &req))
+		result, ok := results[req.Method]
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result}
+		if !ok {
+			delete(resp, "result")
+			resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestTx_CompleteClaim(t *testing.T) {
+	node := newTestNode(t, map[string]interface{}{
+		"getclaimable": map[string]interface{}{
+			"claimable": []interface{}{
+				map[string]interface{}{"txid": "0x4ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cff", "n": 0, "unclaimed": 0.3},
+				map[string]interface{}{"txid": "0x5ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cff", "n": 1, "unclaimed": 0.20000001},
+			},
+			"unclaimed": 0.50000001,
+		},
+	})
+	defer node.Close()
+
+	tx := NewTx("claim")
+	assert.NoError(t, tx.SetType("claim"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Claim = true
+	assert.NoError(t, tx.Complete(NewClient(node.URL)))
+
+	claim, ok := tx.Data.(*transaction.ClaimTX)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(claim.Claims))
+	assert.Equal(t, uint16(1), claim.Claims[1].PrevIndex)
+	assert.Equal(t, 1, len(tx.Outputs))
+	assert.Equal(t, util.Fixed8(50000001), tx.Outputs[0].Amount)
+	assert.Equal(t, 0, len(tx.Inputs))
+
+	//claim needs claim type
+	tx = NewTx("claim")
+	assert.NoError(t, tx.SetType("contract"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Claim = true
+	assert.Error(t, tx.Complete(NewClient(node.URL)))
+
+	tx = NewTx("claim")
+	assert.NoError(t, tx.SetType("claim"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	assert.Error(t, tx.Complete(NewClient(node.URL)))
+}
+
+func TestTx_CompleteClaimNothing(t *testing.T) {
+	node := newTestNode(t, map[string]interface{}{
+		"getclaimable": map[string]interface{}{"claimable": []interface{}{}, "unclaimed": 0},
+	})
+	defer node.Close()
+
+	tx := NewTx("claim")
+	assert.NoError(t, tx.SetType("claim"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Claim = true
+	assert.Error(t, tx.Complete(NewClient(node.URL)))
+}
+
+func TestTx_CompleteSysFee(t *testing.T) {
+	unspent := func(txid string, value float64) map[string]interface{} {
+		return map[string]interface{}{"txid": txid, "n": 0, "value": value}
+	}
+	node := newTestNode(t, map[string]interface{}{
+		"getunspents": map[string]interface{}{
+			"balance": []interface{}{
+				map[string]interface{}{
+					"asset_hash": GasAssetHash,
+					"amount":     8,
+					"unspent": []interface{}{
+						unspent("5ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cf5", 5),
+						unspent("1ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cf1", 1),
+						unspent("2ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cf2", 2),
+					},
+				},
+			},
+		},
+	})
+	defer node.Close()
+
+	tx := NewTx("invoke")
+	assert.NoError(t, tx.SetType("invocation"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Script = []byte{0x51}
+	tx.SetFee(0.5)
+	assert.NoError(t, tx.SetSysFee(2))
+	tx.Param.Vout = append(tx.Param.Vout, goutil.Map{"asset": GasAssetHash, "address": "AWSuQXpjuY3v22gCbEFL2vHbSLMMVK1QD6", "value": 1.0})
+	assert.NoError(t, tx.Complete(NewClient(node.URL)))
+
+	inv, ok := tx.Data.(*transaction.InvocationTX)
+	assert.True(t, ok)
+	assert.Equal(t, util.Fixed8(2e8), inv.Gas)
+	assert.Equal(t, 2.0, tx.ToMap().GetFloat64("gas"))
+
+	//fees are paid by 1 and 2, the vout is paid by 5 without reusing them
+	assert.Equal(t, 3, len(tx.Inputs))
+	var amounts []util.Fixed8
+	for _, out := range tx.Outputs {
+		amounts = append(amounts, out.Amount)
+	}
+	assert.Equal(t, []util.Fixed8{util.Fixed8(0.5e8), util.Fixed8(1e8), util.Fixed8(4e8)}, amounts)
+
+	//system fee needs script
+	tx = NewTx("transfer")
+	assert.NoError(t, tx.SetType("contract"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	assert.NoError(t, tx.SetSysFee(1))
+	assert.Error(t, tx.Complete(NewClient(node.URL)))
+
+	//system fee must be a whole number of gas
+	assert.Error(t, tx.SetSysFee(0.5))
+	assert.Error(t, tx.SetSysFee(-1))
+	assert.Equal(t, util.Fixed8(1e8), tx.Param.SysFee)
+}
+
+func TestEstimateFee(t *testing.T) {
+	assert.Equal(t, 0.0, EstimateSysFee(9.5))
+	assert.Equal(t, 0.0, EstimateSysFee(10))
+	assert.Equal(t, 3.0, EstimateSysFee(12.5))
+	assert.Equal(t, util.Fixed8(0), EstimateNetFee(MaxFreeTxSize))
+	assert.Equal(t, util.Fixed8(0.0011e8), EstimateNetFee(MaxFreeTxSize+10))
+	//exact for every size, e.g. 0.00104 is not 0.0010400000000000001
+	assert.Equal(t, "0.00104", strconv.FormatFloat(Fixed8ToFloat64(EstimateNetFee(1028)), 'f', -1, 64))
+}
+
+func TestTx_CompleteAutoFee(t *testing.T) {
+	results := map[string]interface{}{
+		"invokescript": map[string]interface{}{"state": "HALT, BREAK", "gas_consumed": "12.5", "stack": []interface{}{}},
+		"getunspents": map[string]interface{}{
+			"balance": []interface{}{
+				map[string]interface{}{
+					"asset_hash": GasAssetHash,
+					"amount":     10,
+					"unspent": []interface{}{
+						map[string]interface{}{"txid": "5ba4d1f1acf7c6648ced8824aa2cd3e8f836f59e7071340e0c440d099a508cf5", "n": 0, "value": 10},
+					},
+				},
+			},
+		},
+	}
+	node := newTestNode(t, results)
+	defer node.Close()
+
+	//large script makes tx pay network fee
+	tx := NewTx("invoke")
+	assert.NoError(t, tx.SetType("invocation"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Script = make([]byte, 2000)
+	tx.Param.AutoFee = true
+	tx.Param.AutoSysFee = true
+	assert.NoError(t, tx.Complete(NewClient(node.URL)))
+
+	inv, ok := tx.Data.(*transaction.InvocationTX)
+	assert.True(t, ok)
+	assert.Equal(t, util.Fixed8(3e8), inv.Gas)
+	assert.True(t, tx.Param.Fee >= EstimateNetFee(tx.Size()))
+	assert.Equal(t, 1, len(tx.Inputs))
+	assert.Equal(t, 1, len(tx.Outputs))
+	assert.Equal(t, util.Fixed8(10e8)-tx.Param.Fee-tx.Param.SysFee, tx.Outputs[0].Amount)
+
+	//small tx is free of network fee
+	tx = NewTx("invoke")
+	assert.NoError(t, tx.SetType("invocation"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Script = []byte{0x51}
+	tx.Param.AutoFee = true
+	assert.NoError(t, tx.Complete(NewClient(node.URL)))
+	assert.Equal(t, util.Fixed8(0), tx.Param.Fee)
+	assert.Equal(t, 0, len(tx.Inputs))
+
+	//fault invocation
+	results["invokescript"] = map[string]interface{}{"state": "FAULT, BREAK", "gas_consumed": "0.1"}
+	tx = NewTx("invoke")
+	assert.NoError(t, tx.SetType("invocation"))
+	assert.NoError(t, tx.Param.SetInitiator(testPrivateKey))
+	tx.Param.Script = []byte{0x51}
+	tx.Param.AutoSysFee = true
+	assert.Error(t, tx.Complete(NewClient(node.URL)))
+}
+
+func TestTx_PendingMap(t *testing.T) {
+	tx := NewTx("")
+	assert.NoError(t, tx.SetType("contract"))
+	_, exist := tx.PendingMap()["txid"]
+	assert.False(t, exist)
+
+	//hash is not computed before completing
+	tx.Version = 1
+	expected := NewTx("")
+	assert.NoError(t, expected.SetType("contract"))
+	expected.Version = 1
+	assert.Equal(t, expected.Hash(), tx.Hash())
+}
@@ -47,10 +47,19 @@ func (b BigDecimal) String() string {
 	return v
 }
 
-func Fixed8FromFloat64(v float64) util.Fixed8 {
+//Fixed8FromFloat64 fixed8 of value, it's an error if value has more than 8 decimals
+func Fixed8FromFloat64(v float64) (util.Fixed8, error) {
 	s := strconv.FormatFloat(v, 'f', -1, 64)
-	f, _ := util.Fixed8DecodeString(s)
-	return f
+	f, err := util.Fixed8DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fixed8 value %v: %v", s, err)
+	}
+	return f, nil
+}
+
+//Fixed8ToFloat64 real value of fixed8
+func Fixed8ToFloat64(f util.Fixed8) float64 {
+	return float64(f) / 1e8
 }
 
 func IsGlobalAsset(asset string) bool {
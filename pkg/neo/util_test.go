@@ -14,6 +14,10 @@ func TestBigDecimal_ChangeDecimals(t *testing.T) {
 }
 
 func TestFixed8FromFloat64(t *testing.T) {
-	f := Fixed8FromFloat64(168612.94473278)
+	f, err := Fixed8FromFloat64(168612.94473278)
+	assert.NoError(t, err)
 	assert.Equal(t, "16861294473278", f.String())
+
+	_, err = Fixed8FromFloat64(0.0010400000000000001)
+	assert.Error(t, err)
 }
\ No newline at end of file
@@ -1,6 +1,9 @@
 package pln
 
-import "github.com/fatih/color"
+import (
+	"github.com/fatih/color"
+	"io"
+)
 
 var Verbose bool
 
@@ -10,12 +13,26 @@ func Error(err error) {
 	}
 }
 
+//FError same as Error but writes to w
+func FError(w io.Writer, err error) {
+	if err != nil {
+		color.New(color.FgRed).Fprintln(w, err.Error())
+	}
+}
+
 func InfoVerbose(format string, a ...interface{}) {
 	if Verbose {
 		color.Blue(format, a...)
 	}
 }
 
+//FInfoVerbose same as InfoVerbose but writes to w
+func FInfoVerbose(w io.Writer, format string, a ...interface{}) {
+	if Verbose {
+		color.New(color.FgBlue).Fprintf(w, format+"\n", a...)
+	}
+}
+
 func InfoSuccess(format string, a ...interface{}) {
 	color.Green(format, a...)
 }
@@ -27,3 +44,8 @@ func InfoFail(format string, a ...interface{}) {
 func Warn(format string, a ...interface{}) {
 	color.Yellow(format, a...)
 }
+
+//FWarn same as Warn but writes to w
+func FWarn(w io.Writer, format string, a ...interface{}) {
+	color.New(color.FgYellow).Fprintf(w, format+"\n", a...)
+}
@@ -0,0 +1,131 @@
+package neotest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//CommandFactory create a command at line of source
+type CommandFactory func(line int) Commander
+
+var (
+	registryMu  sync.RWMutex
+	commands    = make(map[string]CommandFactory)
+	subCommands = make(map[string]CommandFactory)
+)
+
+//RegisterCommand register a command by its name which is the first word of a line, so that
+//the command can be used in ntf files. It's usually called in init of the package which implements
+//the command, and it panics if the name is registered already
+func RegisterCommand(name string, factory CommandFactory) {
+	register(commands, "command", name, factory)
+}
+
+//RegisterSubCommand register a sub command which is used in '`...`'. The command must implement
+//ExprNode, e.g. by embedding SubCmd created by NewSubCmd, and it panics if the name is registered already
+func RegisterSubCommand(name string, factory CommandFactory) {
+	if _, ok := factory(0).(ExprNode); !ok {
+		panic(fmt.Sprintf("neotest: sub command %v does not implement ExprNode", name))
+	}
+	register(subCommands, "sub command", name, factory)
+}
+
+func register(registry map[string]CommandFactory, kind, name string, factory CommandFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic(fmt.Sprintf("neotest: %v %v is registered with nil factory", kind, name))
+	}
+	if _, exist := registry[name]; exist {
+		panic(fmt.Sprintf("neotest: %v %v is registered twice", kind, name))
+	}
+	registry[name] = factory
+}
+
+//newCommand create the registered command or sub command
+func newCommand(name string, sub bool, line int) (Commander, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	registry := commands
+	if sub {
+		registry = subCommands
+	}
+	factory, ok := registry[name]
+	if !ok {
+		return nil, false
+	}
+	return factory(line), true
+}
+
+//CommandNames names of all registered commands
+func CommandNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	builtin := map[string]CommandFactory{
+		"echo":            func(line int) Commander { return NewEchoCmd(line) },
+		"if":              func(line int) Commander { return NewIfCmd(line) },
+		"elif":            func(line int) Commander { return NewElifCmd(line) },
+		"else":            func(line int) Commander { return NewElseCmd(line) },
+		"end":             func(line int) Commander { return NewEndCmd(line) },
+		"repeat":          func(line int) Commander { return NewRepeatCmd(line) },
+		"for":             func(line int) Commander { return NewForCmd(line) },
+		"while":           func(line int) Commander { return NewWhileCmd(line) },
+		"def":             func(line int) Commander { return NewDefCmd(line) },
+		"call":            func(line int) Commander { return NewCallCmd(line) },
+		"include":         func(line int) Commander { return NewIncludeCmd(line) },
+		"import":          func(line int) Commander { return NewIncludeCmd(line) },
+		"let":             func(line int) Commander { return NewLetCmd(line) },
+		"equal":           func(line int) Commander { return NewEqualCmd(line) },
+		"not-equal":       func(line int) Commander { return NewNotEqualCmd(line) },
+		"contains":        func(line int) Commander { return NewContainsCmd(line) },
+		"match":           func(line int) Commander { return NewMatchCmd(line) },
+		"gt":              func(line int) Commander { return NewCompareCmd("gt", line) },
+		"lt":              func(line int) Commander { return NewCompareCmd("lt", line) },
+		"ge":              func(line int) Commander { return NewCompareCmd("ge", line) },
+		"le":              func(line int) Commander { return NewCompareCmd("le", line) },
+		"exists":          func(line int) Commander { return NewExistsCmd(line) },
+		"not-exists":      func(line int) Commander { return NewNotExistsCmd(line) },
+		"len-equal":       func(line int) Commander { return NewLenEqualCmd(line) },
+		"type-is":         func(line int) Commander { return NewTypeIsCmd(line) },
+		"case":            func(line int) Commander { return NewCaseCmd(line) },
+		"setup":           func(line int) Commander { return NewSetupCmd(line) },
+		"teardown":        func(line int) Commander { return NewTeardownCmd(line) },
+		"data":            func(line int) Commander { return NewDataCmd(line) },
+		"assert":          func(line int) Commander { return NewAssertCmd(line) },
+		"expect":          func(line int) Commander { return NewExpectCmd(line) },
+		"req":             func(line int) Commander { return NewReqCmd(line) },
+		"body":            func(line int) Commander { return NewBodyCmd(line) },
+		"ret":             func(line int) Commander { return NewRetCmd(line) },
+		"tx":              func(line int) Commander { return NewTxCmd(line) },
+		"tx-v":            func(line int) Commander { return NewTxVCmd(line) },
+		"tx-type":         func(line int) Commander { return NewTxTypeCmd(line) },
+		"tx-fee":          func(line int) Commander { return NewTxFeeCmd(line) },
+		"tx-sysfee":       func(line int) Commander { return NewTxSysFeeCmd(line) },
+		"tx-attr":         func(line int) Commander { return NewTxAttrCmd(line) },
+		"tx-initiator":    func(line int) Commander { return NewTxInitiatorCmd(line) },
+		"tx-vout":         func(line int) Commander { return NewTxVoutCmd(line) },
+		"tx-claim":        func(line int) Commander { return NewTxClaimCmd(line) },
+		"tx-invoke":       func(line int) Commander { return NewTxInvokeCmd(line) },
+		"tx-invokefunc":   func(line int) Commander { return NewTxInvokeFuncCmd(line) },
+		"tx-invokescript": func(line int) Commander { return NewTxInvokeScriptCmd(line) },
+		"tx-invoke-test":  func(line int) Commander { return NewTxInvokeTestCmd(line) },
+		"tx-witness":      func(line int) Commander { return NewTxWitnessCmd(line) },
+		"tx-send":         func(line int) Commander { return NewTxSendCmd(line) },
+	}
+	for name, factory := range builtin {
+		RegisterCommand(name, factory)
+	}
+
+	RegisterSubCommand("env", func(line int) Commander { return NewEnvSubCmd(line) })
+	RegisterSubCommand("addr2scripthash", func(line int) Commander { return NewAddr2ScriptHashSubCmd(line) })
+}
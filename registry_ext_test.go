@@ -0,0 +1,83 @@
+package neotest_test
+
+import (
+	"github.com/hzxiao/goutil"
+	"github.com/hzxiao/goutil/assert"
+	"github.com/hzxiao/neotest"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+//lowerSubCmd sub command registered outside neotest
+type lowerSubCmd struct {
+	neotest.SubCmd
+}
+
+func (sc *lowerSubCmd) Run(vm *neotest.VM) (interface{}, error) {
+	v, err := sc.RunExprIndexOf(0, vm)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(goutil.String(v)), nil
+}
+
+func TestRegisterSubCommand_External(t *testing.T) {
+	neotest.RegisterSubCommand("lower", func(line int) neotest.Commander {
+		return &lowerSubCmd{neotest.NewSubCmd("lower", "lower <string>", line)}
+	})
+
+	f, err := ioutil.TempFile("", "lower*.ntf")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("let @name `lower \"NEO\"`\n")
+	assert.NoError(t, err)
+	f.Close()
+
+	src, err := neotest.NewSource(f.Name())
+	assert.NoError(t, err)
+	cmds, err := src.Parse()
+	assert.NoError(t, err)
+	vm := neotest.NewVM(cmds)
+	assert.NoError(t, vm.Run())
+	name, _ := vm.StringV("name")
+	assert.Equal(t, "neo", name)
+}
+
+//napCmd command registered outside neotest, which checks its argument on parsing
+type napCmd struct {
+	*neotest.Cmd
+}
+
+func (c *napCmd) Exec(vm *neotest.VM) error {
+	return nil
+}
+
+func (c *napCmd) CheckExpr(varType map[string]string) error {
+	return neotest.CheckExprNumAndType(c.ExprList(), []int{1}, neotest.Float)
+}
+
+func TestCheckExprNumAndType_External(t *testing.T) {
+	neotest.RegisterCommand("nap", func(line int) neotest.Commander {
+		return &napCmd{neotest.NewCmd("nap", "nap <seconds>", line)}
+	})
+
+	for text, valid := range map[string]bool{
+		"nap 1":                  true,
+		"let @n 0.1\nnap $(n)":   true,
+		`nap "1"`:                false,
+		"nap":                    false,
+		"let @s \"a\"\nnap $(s)": false,
+	} {
+		f, err := ioutil.TempFile("", "nap*.ntf")
+		assert.NoError(t, err)
+		f.WriteString(text)
+		f.Close()
+		src, err := neotest.NewSource(f.Name())
+		assert.NoError(t, err)
+		_, err = src.Parse()
+		os.Remove(f.Name())
+		assert.Equal(t, valid, err == nil)
+	}
+}
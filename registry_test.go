@@ -0,0 +1,65 @@
+package neotest
+
+import (
+	"bytes"
+	"github.com/hzxiao/goutil/assert"
+	"testing"
+)
+
+type shoutCmd struct {
+	*Cmd
+}
+
+func (c *shoutCmd) Exec(vm *VM) error {
+	v, err := c.RunExprIndexOf(0, vm)
+	if err != nil {
+		return err
+	}
+	_, err = vm.Out.Write([]byte(v.(string) + "!\n"))
+	return err
+}
+
+func (c *shoutCmd) CheckExpr(varType map[string]string) error {
+	return checkExprNumAndType(c.exprList, []int{1}, String)
+}
+
+type upperSubCmd struct {
+	SubCmd
+}
+
+func TestRegisterCommand(t *testing.T) {
+	RegisterCommand("shout", func(line int) Commander {
+		return &shoutCmd{Cmd: NewCmd("shout", "shout <string>", line)}
+	})
+	RegisterSubCommand("upper", func(line int) Commander {
+		return &upperSubCmd{NewSubCmd("upper", "upper case", line)}
+	})
+	assert.True(t, containsString(CommandNames(), "shout"))
+
+	src := newSourceByBytes([]byte("let @a \"neo\"\nshout $(a)\nlet @b `upper`"))
+	cmds, err := src.Parse()
+	assert.NoError(t, err)
+	vm := NewVM(cmds)
+	var buf bytes.Buffer
+	vm.Out = &buf
+	assert.NoError(t, vm.Run())
+	assert.Equal(t, "neo!\n", buf.String())
+
+	_, err = newSourceByBytes([]byte("let @b `shout \"a\"`")).Parse()
+	assert.Error(t, err)
+
+	assert.True(t, panics(func() {
+		RegisterCommand("echo", func(line int) Commander { return NewEchoCmd(line) })
+	}))
+	assert.True(t, panics(func() {
+		RegisterSubCommand("loud", func(line int) Commander { return NewEchoCmd(line) })
+	}))
+}
+
+func panics(fn func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	fn()
+	return
+}
@@ -0,0 +1,133 @@
+package neotest
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hzxiao/goutil"
+	"sort"
+	"strings"
+)
+
+//Repl read-eval-print loop, the input is parsed and executed immediately against a persistent vm,
+//so that variables, procedures and the pending tx are kept between inputs
+type Repl struct {
+	src *Source
+	VM  *VM
+}
+
+func NewRepl() *Repl {
+	return &Repl{
+		src: newSourceByBytes(nil),
+		VM:  NewVM(nil),
+	}
+}
+
+//Eval parse and execute input. If the input ends inside a block or a multi-line string,
+//more is true and nothing is executed, the caller should append the next line to input and eval again
+func (r *Repl) Eval(input string) (more bool, err error) {
+	if unclosedString(input) {
+		return true, nil
+	}
+
+	//declarations of input which fails to parse are dropped
+	varType := r.src.saveVarType()
+	procs := make(map[string]*DefCmd, len(r.src.procs))
+	for k, v := range r.src.procs {
+		procs[k] = v
+	}
+
+	r.src.buf = bufio.NewScanner(strings.NewReader(input))
+	r.src.buf.Split(splitCmd)
+	r.src.curLine = 0
+	cmds, delim, err := r.src.parseBlock()
+	if err == nil && delim != nil {
+		err = blockError(delim, fmt.Errorf("unexpected %v", delim.Name()))
+	}
+	if err != nil {
+		r.src.restoreVarType(varType)
+		r.src.procs = procs
+		if e, ok := err.(*ParseError); ok {
+			_, more = e.Err.(*notClosedError)
+		}
+		if more {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, r.VM.execCommands(cmds)
+}
+
+//unclosedString whether input ends inside a multi-line string, which is closed by a quote at the end of line
+func unclosedString(input string) bool {
+	for len(input) > 0 {
+		line := input
+		if end := strings.IndexByte(input, '\n'); end >= 0 {
+			line = input[:end+1]
+		}
+		i := strings.IndexByte(line, '\'')
+		if i < 0 {
+			input = input[len(line):]
+			continue
+		}
+		j := strings.Index(input[i+1:], "'\n")
+		if j < 0 {
+			return true
+		}
+		input = input[i+1+j+2:]
+	}
+	return false
+}
+
+//VarNames names of declared variables
+func (r *Repl) VarNames() []string {
+	var names []string
+	for name := range r.src.varType {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Complete candidates of line whose last word is completed, the first word is completed
+//with command names and a word starts with '$(' is completed with variable names
+func (r *Repl) Complete(line string) []string {
+	i := strings.LastIndexAny(line, " \t") + 1
+	head, word := line[:i], line[i:]
+
+	var words []string
+	switch {
+	case strings.HasPrefix(word, "$("):
+		for _, name := range r.VarNames() {
+			words = append(words, "$("+name+")")
+		}
+	case strings.TrimSpace(head) == "" || isPrefixCmd(strings.TrimSpace(head)):
+		words = CommandNames()
+	}
+
+	var candidates []string
+	for _, w := range words {
+		if strings.HasPrefix(w, word) {
+			candidates = append(candidates, head+w)
+		}
+	}
+	return candidates
+}
+
+func isPrefixCmd(name string) bool {
+	return name == "assert" || name == "expect"
+}
+
+//Vars variables visible in current scope
+func (vm *VM) Vars() goutil.Map {
+	if len(vm.scopes) == 0 {
+		return vm.variable
+	}
+	vars := goutil.Map{}
+	for k, v := range vm.variable {
+		vars[k] = v
+	}
+	for k, v := range vm.scopes[len(vm.scopes)-1] {
+		vars[k] = v
+	}
+	return vars
+}
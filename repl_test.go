@@ -0,0 +1,70 @@
+package neotest
+
+import (
+	"bytes"
+	"github.com/hzxiao/goutil/assert"
+	"testing"
+)
+
+func TestRepl_Eval(t *testing.T) {
+	r := NewRepl()
+	var buf bytes.Buffer
+	r.VM.Out = &buf
+
+	more, err := r.Eval("let @a 1\n")
+	assert.NoError(t, err)
+	assert.False(t, more)
+	v, _ := r.VM.Var("a")
+	assert.Equal(t, float64(1), v)
+
+	//block is executed after end
+	more, err = r.Eval("if true\n")
+	assert.NoError(t, err)
+	assert.True(t, more)
+	more, err = r.Eval("if true\n    echo $(a)\n")
+	assert.True(t, more)
+	more, err = r.Eval("if true\n    echo $(a)\nend\n")
+	assert.NoError(t, err)
+	assert.False(t, more)
+	assert.Equal(t, "1 \n", buf.String())
+
+	//multi-line string
+	more, _ = r.Eval("let @s '[\n")
+	assert.True(t, more)
+	more, err = r.Eval("let @s '[\n1]'\n")
+	assert.NoError(t, err)
+	assert.False(t, more)
+
+	//declarations are dropped on parse error
+	_, err = r.Eval("let @b 1\nfoo\n")
+	assert.Error(t, err)
+	_, err = r.Eval("echo $(b)\n")
+	assert.Error(t, err)
+
+	//procedure is kept
+	_, err = r.Eval("def p @x\n    let @y $(x)\nend\n")
+	assert.NoError(t, err)
+	_, err = r.Eval("call p 2\n")
+	assert.NoError(t, err)
+	_, err = r.Eval("end\n")
+	assert.Error(t, err)
+}
+
+func TestRepl_Complete(t *testing.T) {
+	r := NewRepl()
+	_, err := r.Eval("let @amount 1\nlet @addr \"a\"\n")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"tx-invoke", "tx-invoke-test", "tx-invokefunc", "tx-invokescript"}, r.Complete("tx-invoke"))
+	assert.Equal(t, []string{"  echo"}, r.Complete("  ec"))
+	assert.Equal(t, []string{"expect equal"}, r.Complete("expect equ"))
+	assert.Equal(t, []string{"echo $(addr)", "echo $(amount)"}, r.Complete("echo $(a"))
+	assert.Equal(t, 0, len(r.Complete("echo ec")))
+}
+
+func TestUnclosedString(t *testing.T) {
+	assert.False(t, unclosedString("echo 1\n"))
+	assert.True(t, unclosedString("body '{\n"))
+	assert.False(t, unclosedString("body '{\n}'\necho 1\n"))
+	assert.True(t, unclosedString("body '{}'\nbody '[\n"))
+}
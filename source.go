@@ -5,16 +5,54 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+//ParseError error on source-parsing stage, column is 1-based and 0 if unknown
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line: %v, err: %v", e.Line, e.Err)
+}
+
+//newParseError create parse error at column of current line, the nested parse error is unwrapped
+func (src *Source) newParseError(column int, err error) *ParseError {
+	if e, ok := err.(*ParseError); ok {
+		err = e.Err
+	}
+	return &ParseError{File: src.file, Line: src.curLine, Column: column, Err: err}
+}
+
 //Source parse source file into commands
 type Source struct {
 	buf     *bufio.Scanner
 	curLine int
 	varType map[string]string
+	procs   map[string]*DefCmd
+	depth   int       //depth of block being parsed
+	block   Commander //innermost block being parsed
+
+	//errors are collected instead of stopping parsing in recovering mode, see Check
+	recovering bool
+	silent     int //errors are dropped if > 0, e.g. in body of a block which fails to parse
+	errs       *[]*ParseError
+
+	//lines are recorded for formatting, see Format
+	recording bool
+	lines     []*formatLine
+
+	file     string
+	includes []string        //chain of files including this source, used to detect cycle
+	included map[string]bool //files already included
+	tags     []string
 }
 
 func NewSource(filename string) (*Source, error) {
@@ -22,19 +60,145 @@ func NewSource(filename string) (*Source, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newSourceByBytes(bs), nil
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	src := newSourceByBytes(bs)
+	src.file = filename
+	src.includes = []string{abs}
+	src.included[abs] = true
+	return src, nil
 }
 
 func newSourceByBytes(data []byte) *Source {
 	src := &Source{}
 	src.buf = bufio.NewScanner(bytes.NewBuffer(data))
 	src.buf.Split(splitCmd)
+	src.tags = parseTags(data)
 	src.varType = make(map[string]string)
+	src.procs = make(map[string]*DefCmd)
+	src.included = make(map[string]bool)
 	return src
 }
 
+//Tags tags declared in header comments of source
+func (src *Source) Tags() []string {
+	return src.tags
+}
+
+var tagsRegexp = regexp.MustCompile(`^#\s*@tags:(.*)$`)
+
+//parseTags parse tags in the comments before the first command, e.g. '# @tags: smoke, nep5'
+func parseTags(data []byte) []string {
+	var tags []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		m := tagsRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, tag := range strings.Split(m[1], ",") {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !containsString(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+//include parse file which is relative to current source, variable types and procedures are shared.
+//A file is only included once, the later including is ignored.
+func (src *Source) include(filename string) ([]Commander, string, error) {
+	if !filepath.IsAbs(filename) && src.file != "" {
+		filename = filepath.Join(filepath.Dir(src.file), filename)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, filename, err
+	}
+	for i, f := range src.includes {
+		if f == abs {
+			chain := append(append([]string{}, src.includes[i:]...), abs)
+			return nil, filename, fmt.Errorf("include cycle: %v", strings.Join(chain, " -> "))
+		}
+	}
+	if src.included[abs] {
+		return nil, filename, nil
+	}
+
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, filename, err
+	}
+	child := newSourceByBytes(bs)
+	child.varType = src.varType
+	child.procs = src.procs
+	child.depth = src.depth
+	child.file = filename
+	child.includes = append(append([]string{}, src.includes...), abs)
+	child.included = src.included
+	child.recovering = src.recovering
+	child.silent = src.silent
+	child.errs = src.errs
+	src.included[abs] = true
+
+	cmds, err := child.Parse()
+	if err != nil && src.recovering {
+		child.addError(err)
+		return nil, filename, nil
+	}
+	if err != nil {
+		return nil, filename, fmt.Errorf("file: %v, %v", filename, err)
+	}
+	return cmds, filename, nil
+}
+
 //Parse parse all command line by line
 func (src *Source) Parse() ([]Commander, error) {
+	cmds, delim, err := src.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+	if delim != nil {
+		return nil, blockError(delim, fmt.Errorf("unexpected %v", delim.Name()))
+	}
+	_, err = findData(cmds)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	return cmds, nil
+}
+
+//saveVarType copy variable types, it's used to restore after parsing a block with isolated scope
+func (src *Source) saveVarType() map[string]string {
+	saved := make(map[string]string, len(src.varType))
+	for k, v := range src.varType {
+		saved[k] = v
+	}
+	return saved
+}
+
+//restoreVarType restore variable types in place, because the map is shared with included sources
+func (src *Source) restoreVarType(saved map[string]string) {
+	for k := range src.varType {
+		delete(src.varType, k)
+	}
+	for k, v := range saved {
+		src.varType[k] = v
+	}
+}
+
+//parseBlock parse commands until EOF or a block delimiter(elif, else, end) which will be returned
+func (src *Source) parseBlock() ([]Commander, Commander, error) {
 	var cmds []Commander
 	for src.buf.Scan() {
 		text := src.buf.Text()
@@ -42,88 +206,227 @@ func (src *Source) Parse() ([]Commander, error) {
 		line := strings.Count(text, "\n")
 		text = strings.Trim(text, " \t\n")
 		if text == "" || strings.HasPrefix(text, "#") {
+			src.record(text, nil)
 			src.curLine += line
 			continue
 		}
+		indent := len(src.buf.Text()) - len(strings.TrimLeft(src.buf.Text(), " \t\n"))
 		cmd, err := src.ParseCmd(text, false)
 		if err != nil {
-			return nil, fmt.Errorf("line: %v, err: %v", src.curLine, err)
+			e, ok := err.(*ParseError)
+			if !ok {
+				e = src.newParseError(0, err)
+			}
+			if e.Column > 0 {
+				e.Column += indent
+			}
+			if !src.recovering {
+				return nil, nil, e
+			}
+			src.addError(e)
 		}
+		src.curLine += line
 
+		if err != nil {
+			//keep the structure of blocks on recovering
+			switch cmd := cmd.(type) {
+			case *ElifCmd:
+				if len(cmd.exprList) == 0 {
+					cmd.AddExpr(newBoolExpr("true"))
+				}
+				return cmds, cmd, nil
+			case Delimiter:
+				return cmds, cmd, nil
+			case Block:
+				src.silent++
+				err = src.skipBlock(cmd)
+				src.silent--
+				if err != nil {
+					src.addError(err)
+				}
+			}
+			continue
+		}
+
+		if t, ok := cmd.(texter); ok {
+			t.setText(t.Text(), t.Column()+indent)
+		}
+		src.record(text, cmd)
+		if _, ok := cmd.(Delimiter); ok {
+			return cmds, cmd, nil
+		}
+		if block, ok := cmd.(Block); ok {
+			parent := src.block
+			src.depth++
+			src.block = block
+			err = block.ParseBlock(src)
+			src.depth--
+			src.block = parent
+			if err != nil {
+				if !src.recovering {
+					return nil, nil, err
+				}
+				src.addError(err)
+				continue
+			}
+		}
 		cmds = append(cmds, cmd)
-		src.curLine += line
 	}
-	return cmds, nil
+	return cmds, nil, nil
+}
+
+//parseBody parse body of a block which must be closed by 'end'
+func (src *Source) parseBody(block Commander) ([]Commander, error) {
+	var body []Commander
+	for {
+		cmds, delim, err := src.parseBlock()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, cmds...)
+		switch delim.(type) {
+		case nil:
+			return nil, blockError(block, &notClosedError{block.Name()})
+		case *EndCmd:
+			return body, nil
+		}
+		err = src.fail(blockError(delim, fmt.Errorf("unexpected %v in %v", delim.Name(), block.Name())))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//skipBlock parse and drop the body of a block which fails to parse, until the matched 'end'
+func (src *Source) skipBlock(block Commander) error {
+	for {
+		_, delim, err := src.parseBlock()
+		if err != nil {
+			return err
+		}
+		switch delim.(type) {
+		case nil:
+			return blockError(block, &notClosedError{block.Name()})
+		case *EndCmd:
+			return nil
+		}
+	}
+}
+
+//addError collect error on recovering
+func (src *Source) addError(err error) {
+	if src.silent > 0 {
+		return
+	}
+	e, ok := err.(*ParseError)
+	if !ok {
+		e = src.newParseError(0, err)
+	}
+	if e.File == "" {
+		e.File = src.file
+	}
+	*src.errs = append(*src.errs, e)
+}
+
+//fail return the error, or record it and return nil to go on parsing on recovering
+func (src *Source) fail(err error) error {
+	if !src.recovering {
+		return err
+	}
+	src.addError(err)
+	return nil
+}
+
+//Check parse all commands and check them without executing, every error is returned instead of the first
+func (src *Source) Check() []*ParseError {
+	var errs []*ParseError
+	src.recovering = true
+	src.errs = &errs
+	_, err := src.Parse()
+	if err != nil {
+		src.addError(err)
+	}
+	return errs
+}
+
+//notClosedError a block is not closed by 'end' until EOF
+type notClosedError struct {
+	block string
+}
+
+func (e *notClosedError) Error() string {
+	return fmt.Sprintf("%v is not closed by end", e.block)
+}
+
+//blockError error at line and column of command
+func blockError(cmd Commander, err error) *ParseError {
+	e := &ParseError{Line: cmd.Line(), Err: err}
+	if t, ok := cmd.(texter); ok {
+		e.Column = t.Column()
+	}
+	return e
 }
 
 //ParseCmd parse a special cmd by one-line string
 func (src *Source) ParseCmd(text string, sub bool) (Commander, error) {
 	scan := bufio.NewScanner(strings.NewReader(text))
-	scan.Split(splitExpr)
+	//column of current token
+	var offset, column int
+	scan.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := splitExpr(data, atEOF)
+		if token != nil {
+			column = offset + cap(data) - cap(token) + 1
+		}
+		offset += advance
+		return advance, token, err
+	})
 
 	var cmdName string
 	if scan.Scan() {
 		cmdName = scan.Text()
 	}
-	var cmd Commander
-	if !sub {
-		switch cmdName {
-		case "echo":
-			cmd = NewEchoCmd(src.curLine)
-		case "let":
-			cmd = NewLetCmd(src.curLine)
-		case "equal":
-			cmd = NewEqualCmd(src.curLine)
-		case "req":
-			cmd = NewReqCmd(src.curLine)
-		case "body":
-			cmd = NewBodyCmd(src.curLine)
-		case "ret":
-			cmd = NewRetCmd(src.curLine)
-		case "tx":
-			cmd = NewTxCmd(src.curLine)
-		case "tx-v":
-			cmd = NewTxVCmd(src.curLine)
-		case "tx-type":
-			cmd = NewTxTypeCmd(src.curLine)
-		case "tx-fee":
-			cmd = NewTxFeeCmd(src.curLine)
-		case "tx-attr":
-			cmd = NewTxAttrCmd(src.curLine)
-		case "tx-initiator":
-			cmd = NewTxInitiatorCmd(src.curLine)
-		case "tx-vout":
-			cmd = NewTxVoutCmd(src.curLine)
-		case "tx-invoke":
-			cmd = NewTxInvokeCmd(src.curLine)
-		case "tx-invokefunc":
-			cmd = NewTxInvokeFuncCmd(src.curLine)
-		case "tx-invokescript":
-			cmd = NewTxInvokeScriptCmd(src.curLine)
-		case "tx-witness":
-			cmd = NewTxWitnessCmd(src.curLine)
-		case "tx-send":
-			cmd = NewTxSendCmd(src.curLine)
-		default:
-			return nil, fmt.Errorf("unknown cmd: %v", cmdName)
-		}
-	} else {
-		switch cmdName {
-		case "env":
-			cmd = NewEnvSubCmd(src.curLine)
-		case "addr2scripthash":
-			cmd = NewAddr2ScriptHashSubCmd(src.curLine)
-		default:
-			return nil, fmt.Errorf("unknown sub cmd: %v", cmdName)
+	cmdColumn := column
+	cmd, ok := newCommand(cmdName, sub, src.curLine)
+	if !ok && sub {
+		return nil, src.newParseError(cmdColumn, fmt.Errorf("unknown sub cmd: %v", cmdName))
+	}
+	if !ok {
+		return nil, src.newParseError(cmdColumn, fmt.Errorf("unknown cmd: %v", cmdName))
+	}
+
+	if t, ok := cmd.(texter); ok {
+		t.setText(strings.Trim(text, " \t\n"), cmdColumn)
+	}
+
+	//the rest of text is another command
+	if prefix, ok := cmd.(Prefixer); ok {
+		rest := strings.TrimPrefix(strings.TrimLeft(text, " \t\n"), cmdName)
+		inner, err := src.ParseCmd(rest, false)
+		if err != nil {
+			e := src.newParseError(cmdColumn, err)
+			if inner, ok := err.(*ParseError); ok && inner.Column > 0 {
+				e.Column = cmdColumn + len(cmdName) + inner.Column - 1
+			}
+			return cmd, e
 		}
+		err = prefix.SetCmd(inner)
+		if err != nil {
+			return cmd, src.newParseError(cmdColumn, err)
+		}
+		return cmd, nil
 	}
 
 	//parse expr
+	kw, _ := cmd.(Keyworder)
 	for scan.Scan() {
 		text := scan.Text()
+		if kw != nil && kw.IsKeyword(len(cmd.ExprList()), text) {
+			cmd.AddExpr(&keywordExpr{word: text})
+			continue
+		}
 		expr, err := src.ParseExpr(text)
 		if err != nil {
-			return nil, err
+			return cmd, src.newParseError(column, err)
 		}
 
 		cmd.AddExpr(expr)
@@ -131,7 +434,13 @@ func (src *Source) ParseCmd(text string, sub bool) (Commander, error) {
 
 	err := cmd.CheckExpr(src.varType)
 	if err != nil {
-		return nil, err
+		return cmd, src.newParseError(cmdColumn, err)
+	}
+	if linker, ok := cmd.(Linker); ok {
+		err = linker.Link(src)
+		if err != nil {
+			return cmd, src.newParseError(cmdColumn, err)
+		}
 	}
 	return cmd, nil
 }
@@ -153,6 +462,12 @@ func (src *Source) ParseExpr(text string) (ExprNode, error) {
 		return src.parseStringExpr(strings.Trim(text, "'"))
 	case strings.HasPrefix(text, "`") && strings.HasSuffix(text, "`"): // sub command expr
 		return src.ParseSubCmdExpr(strings.Trim(text, "`"))
+	case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"): //array expr
+		return src.parseArrayExpr(text)
+	case strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}"): //map expr
+		return src.parseMapExpr(text)
+	case strings.HasPrefix(text, "((") && strings.HasSuffix(text, "))"): //arithmetic expr
+		return src.parseArithExpr(text[2 : len(text)-2])
 	case strings.HasPrefix(text, "$(") && strings.HasSuffix(text, ")"): //var
 		return src.parseExprByVqr(text)
 	case strings.HasPrefix(text, "@"): //ID expr
@@ -180,20 +495,10 @@ func (src *Source) parseIDExpr(ID string) (ExprNode, error) {
 
 func (src *Source) parseExprByVqr(IDFull string) (ExprNode, error) {
 	ID, _ := isVar(IDFull)
-	isInternal, err := CheckInternalVarID(ID)
+	Type, err := TypeOfVar(ID, src.varType)
 	if err != nil {
 		return nil, err
 	}
-	var Type string
-	if isInternal {
-		Type = "internal"
-	} else {
-		var exist bool
-		Type, exist = src.varType[ID]
-		if !exist {
-			return nil, fmt.Errorf("%v: %v", ErrVariableUndefine.Error(), ID)
-		}
-	}
 
 	var expr ExprNode
 	switch Type {
@@ -203,6 +508,10 @@ func (src *Source) parseExprByVqr(IDFull string) (ExprNode, error) {
 		expr = newStringExpr(IDFull)
 	case "float":
 		expr = newFloatExpr(IDFull)
+	case "array":
+		expr = &arrayExpr{varExpr: varExpr{val: IDFull}}
+	case "map":
+		expr = &mapExpr{varExpr: varExpr{val: IDFull}}
 	case "internal":
 		expr = newInternalValExpr(IDFull)
 	default:
@@ -211,6 +520,103 @@ func (src *Source) parseExprByVqr(IDFull string) (ExprNode, error) {
 	return expr, nil
 }
 
+//parseArrayExpr parse array literal, e.g. [1, "a", $(v)]
+func (src *Source) parseArrayExpr(text string) (ExprNode, error) {
+	items, err := splitElements(text[1 : len(text)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	expr := &arrayExpr{}
+	for _, item := range items {
+		elem, err := src.ParseExpr(item)
+		if err != nil {
+			return nil, err
+		}
+		if elem.Type() == Identity || elem.Type() == Keyword {
+			return nil, fmt.Errorf("invalid array element: %v", item)
+		}
+		elem.SetParent(expr)
+		expr.children = append(expr.children, elem)
+	}
+	return expr, nil
+}
+
+//parseMapExpr parse map literal, e.g. {"k": $(v), k2: 1}
+func (src *Source) parseMapExpr(text string) (ExprNode, error) {
+	items, err := splitElements(text[1 : len(text)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	expr := &mapExpr{}
+	for _, item := range items {
+		i := strings.IndexByte(item, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid map element: %v", item)
+		}
+		key := strings.Trim(item[:i], " \t\n")
+		if len(key) >= 2 && strings.HasPrefix(key, "\"") && strings.HasSuffix(key, "\"") {
+			key = key[1 : len(key)-1]
+		} else if !ValidID(key) {
+			return nil, fmt.Errorf("invalid map key: %v", key)
+		}
+		if containsString(expr.keys, key) {
+			return nil, fmt.Errorf("duplicate map key: %v", key)
+		}
+
+		value, err := src.ParseExpr(item[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		if value.Type() == Identity || value.Type() == Keyword {
+			return nil, fmt.Errorf("invalid map value: %v", item[i+1:])
+		}
+		value.SetParent(expr)
+		expr.keys = append(expr.keys, key)
+		expr.children = append(expr.children, value)
+	}
+	return expr, nil
+}
+
+//splitElements split elements of array or map literal by comma, nested literal and quoted text are skipped
+func splitElements(text string) ([]string, error) {
+	if strings.Trim(text, " \t\n") == "" {
+		return nil, nil
+	}
+
+	var items []string
+	var depth, start = 0, 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"', '\'', '`':
+			j := strings.IndexByte(text[i+1:], text[i])
+			if j < 0 {
+				return nil, fmt.Errorf("unclosed %c in literal", text[i])
+			}
+			i += j + 1
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	items = append(items, text[start:])
+
+	for i := range items {
+		items[i] = strings.Trim(items[i], " \t\n")
+		if items[i] == "" {
+			return nil, fmt.Errorf("empty element in literal")
+		}
+	}
+	return items, nil
+}
+
 //parseStringExpr check var if text contains
 func (src *Source) parseStringExpr(text string) (ExprNode, error) {
 	all := regexp.MustCompile(`\$\(.*?\)`).FindAllString(text, -1)
@@ -256,6 +662,19 @@ func splitExpr(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		}
 		return advance, token, false
 	}
+	//find arithmetic expr
+	if bytes.HasPrefix(token, []byte("((")) {
+		return findBalanced(data, atEOF, '(', ')')
+	}
+
+	//find array or map expr
+	if bytes.HasPrefix(token, []byte("[")) {
+		return findBalanced(data, atEOF, '[', ']')
+	}
+	if bytes.HasPrefix(token, []byte("{")) {
+		return findBalanced(data, atEOF, '{', '}')
+	}
+
 	//find sub cmd expr
 	var ok bool
 	advance, token, ok = findEndpoint('`')
@@ -273,6 +692,35 @@ func splitExpr(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	return
 }
 
+//findBalanced find the token starts with left char and ends with the matched right char, quoted text is skipped
+func findBalanced(data []byte, atEOF bool, left, right byte) (advance int, token []byte, err error) {
+	start := len(data) - len(bytes.TrimLeft(data, " \t\r\n"))
+	depth := 0
+	for j := start; j < len(data); j++ {
+		switch data[j] {
+		case '"', '\'', '`':
+			k := bytes.IndexByte(data[j+1:], data[j])
+			if k < 0 {
+				j = len(data)
+				continue
+			}
+			j += k + 1
+		case left:
+			depth++
+		case right:
+			depth--
+			if depth == 0 {
+				return j + 1, data[start : j+1], nil
+			}
+		}
+	}
+
+	if atEOF {
+		return len(data), data[start:], nil
+	}
+	return 0, nil, nil
+}
+
 func splitCmd(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	advance, token, err = bufio.ScanLines(data, atEOF)
 	if err != nil {
@@ -302,15 +750,46 @@ func ValidID(ID string) bool {
 }
 
 func CheckVar(ID string, varType map[string]string) error {
+	_, err := TypeOfVar(ID, varType)
+	return err
+}
+
+//TypeOfVar get type of variable on source-parsing stage, the path of variable(e.g. a.b, a[0]) is typed as internal
+func TypeOfVar(ID string, varType map[string]string) (string, error) {
+	root := varRoot(ID)
+	if typ, exist := varType[root]; exist {
+		if root == ID {
+			return typ, nil
+		}
+		return "internal", nil
+	}
+	if _, exist := internalVarMap[ID]; exist {
+		return "internal", nil
+	}
+
 	isInternal, err := CheckInternalVarID(ID)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if !isInternal {
+		return "", fmt.Errorf("%v: %v", ErrVariableUndefine.Error(), ID)
 	}
-	if isInternal {
-		return nil
+	return "internal", nil
+}
+
+//varRoot get root variable of path, e.g. 'a' of 'a.b[0]'
+func varRoot(ID string) string {
+	if i := strings.IndexAny(ID, ".["); i >= 0 {
+		return ID[:i]
 	}
-	if _, ok := varType[ID]; !ok {
-		return fmt.Errorf("%v: %v", ErrVariableUndefine.Error(), ID)
+	return ID
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
 	}
-	return nil
+	return false
 }
@@ -27,6 +27,10 @@ func TestSplitExpr(t *testing.T) {
 	assert.Equal(t, []string{"let", "@a", "`base64 \"abc$(v)\"`"}, splitRawExpr("let @a `base64 \"abc$(v)\"`"))
 
 	assert.Equal(t, []string{"echo", `"a"`, `"b"`, "2"}, splitRawExpr(`echo "a" "b" 2`))
+	assert.Equal(t, []string{"let", "@a", "(( 1 + (2 * 3) ))"}, splitRawExpr("let @a (( 1 + (2 * 3) ))"))
+	assert.Equal(t, []string{"if", `(( "a)" + $(b) == "a)b" ))`, "1"}, splitRawExpr(`if (( "a)" + $(b) == "a)b" )) 1`))
+	assert.Equal(t, []string{"let", "@a", `[1, "]", [2, 3]]`}, splitRawExpr(`let @a [1, "]", [2, 3]]`))
+	assert.Equal(t, []string{"let", "@m", `{"k": {"v": 1}}`, "2"}, splitRawExpr(`let @m {"k": {"v": 1}} 2`))
 }
 
 func splitRawExpr(text string) []string {
@@ -76,4 +80,63 @@ func splitRawCmd(text string) []string {
 	}
 
 	return rawExprs
-}
\ No newline at end of file
+}
+
+func TestSource_ParseBlock(t *testing.T) {
+	cmds, err := newSourceByBytes([]byte(`let @a true
+if $(a)
+    echo 1
+elif false
+    echo 2
+else
+    if true
+        echo 3
+    end
+end
+echo 4`)).Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(cmds))
+	assert.Equal(t, 3, len(cmds[1].(*IfCmd).branches))
+
+	var invalid = []string{
+		"if true\necho 1",
+		"echo 1\nend",
+		"else",
+		"if true\nelse\nelif true\nend",
+		"if true\nelse\nelse\nend",
+		"if 1\nend",
+		"if true\nend 1",
+	}
+	for _, text := range invalid {
+		_, err = newSourceByBytes([]byte(text)).Parse()
+		assert.Error(t, err)
+	}
+}
+
+func TestSource_Tags(t *testing.T) {
+	src := newSourceByBytes([]byte("# test\n\n# @tags: smoke, nep5\n#@tags: smoke,slow\necho 1\n# @tags: ignored"))
+	assert.Equal(t, []string{"smoke", "nep5", "slow"}, src.Tags())
+
+	src = newSourceByBytes([]byte("echo 1\n# @tags: smoke"))
+	assert.Equal(t, 0, len(src.Tags()))
+}
+
+func TestSource_Check(t *testing.T) {
+	src := newSourceByBytes([]byte("let @a 1\nfoo 1\nif $(a)\n    echo $(b)\nend\n  echo $(c)\necho $(a)"))
+	errs := src.Check()
+	assert.Equal(t, 3, len(errs))
+	assert.Equal(t, []int{2, 1}, []int{errs[0].Line, errs[0].Column})
+	assert.Equal(t, "unknown cmd: foo", errs[0].Err.Error())
+	//body of if which fails is skipped
+	assert.Equal(t, []int{3, 1}, []int{errs[1].Line, errs[1].Column})
+	assert.Equal(t, []int{6, 8}, []int{errs[2].Line, errs[2].Column})
+
+	src = newSourceByBytes([]byte("case \"a\"\n    else\n    echo 1\nend\nend"))
+	errs = src.Check()
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "unexpected else in case", errs[0].Err.Error())
+	assert.Equal(t, []int{5, 1}, []int{errs[1].Line, errs[1].Column})
+
+	src = newSourceByBytes([]byte("echo 1"))
+	assert.Equal(t, 0, len(src.Check()))
+}
@@ -16,11 +16,19 @@ type Resultant interface {
 	ResultType() string
 }
 
+//SubCmd base of sub command which is used in '`...`', it should be created by NewSubCmd
 type SubCmd struct {
 	*Cmd
 	*varExpr
 }
 
+func NewSubCmd(name, usage string, line int) SubCmd {
+	return SubCmd{
+		Cmd:     NewCmd(name, usage, line),
+		varExpr: &varExpr{},
+	}
+}
+
 func (*SubCmd) Exec(vm *VM) error {
 	return nil
 }
@@ -57,10 +65,7 @@ type EnvSubCmd struct {
 
 func NewEnvSubCmd(line int) *EnvSubCmd {
 	return &EnvSubCmd{
-		SubCmd{
-			Cmd:     NewCmd("env", "get env value from os", line),
-			varExpr: &varExpr{},
-		},
+		NewSubCmd("env", "get env value from os", line),
 	}
 }
 
@@ -90,10 +95,7 @@ type Addr2ScriptHashSubCmd struct {
 
 func NewAddr2ScriptHashSubCmd(line int) *Addr2ScriptHashSubCmd {
 	return &Addr2ScriptHashSubCmd{
-		SubCmd{
-			Cmd:     NewCmd("addr2scripthash", "convert address to script hash", line),
-			varExpr: &varExpr{},
-		},
+		NewSubCmd("addr2scripthash", "convert address to script hash", line),
 	}
 }
 
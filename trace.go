@@ -0,0 +1,118 @@
+package neotest
+
+import (
+	"encoding/json"
+	"github.com/hzxiao/goutil"
+	"github.com/hzxiao/neotest/pkg/neo"
+	"time"
+)
+
+//Step record of an executed command, it's recorded only if VM.Trace is on
+type Step struct {
+	File       string
+	Line       int
+	Cmd        string
+	Text       string
+	Depth      int
+	Duration   time.Duration
+	Err        error
+	Assertions []*Assertion
+	Tx         goutil.Map
+	TxHex      string
+	Exchanges  []*Exchange
+}
+
+//Exchange a http or rpc request and its response
+type Exchange struct {
+	Kind     string
+	Method   string
+	URL      string
+	Request  string
+	Response string
+	Code     int
+	Err      string
+}
+
+//Steps all commands executed by vm
+func (vm *VM) Steps() []*Step {
+	return vm.steps
+}
+
+//execCommand exec a command after the hook of debugger, and record it as a step if trace is on
+func (vm *VM) execCommand(cmd Commander) error {
+	vm.depth++
+	defer func() { vm.depth-- }()
+	if vm.hook != nil {
+		err := vm.hook(cmd, vm.depth)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !vm.Trace {
+		return cmd.Exec(vm)
+	}
+
+	step := &Step{File: vm.File, Line: cmd.Line(), Cmd: cmd.Name(), Depth: len(vm.stepStack)}
+	if t, ok := cmd.(texter); ok {
+		step.Text = t.Text()
+	}
+	vm.steps = append(vm.steps, step)
+	vm.stepStack = append(vm.stepStack, step)
+	start := time.Now()
+
+	err := cmd.Exec(vm)
+
+	step.Duration = time.Since(start)
+	step.Err = err
+	vm.stepStack = vm.stepStack[:len(vm.stepStack)-1]
+	return err
+}
+
+//curStep step of the innermost executing command
+func (vm *VM) curStep() *Step {
+	if len(vm.stepStack) == 0 {
+		return nil
+	}
+	return vm.stepStack[len(vm.stepStack)-1]
+}
+
+//traceTx record the tx to be sent
+func (vm *VM) traceTx(tx *neo.Tx) {
+	step := vm.curStep()
+	if step == nil || tx == nil {
+		return
+	}
+	step.Tx = tx.ToMap()
+	step.TxHex, _ = tx.RawHex()
+}
+
+//traceExchange record a http or rpc exchange
+func (vm *VM) traceExchange(ex *Exchange) {
+	if step := vm.curStep(); step != nil {
+		step.Exchanges = append(step.Exchanges, ex)
+	}
+}
+
+//rpcClient rpc client of node, the exchanges are recorded if trace is on
+func (vm *VM) rpcClient(node string) *neo.Client {
+	client := neo.NewClient(node)
+	if vm.Trace {
+		client.Observer = vm.traceRpc
+	}
+	return client
+}
+
+//traceRpc observer of rpc clients of vm
+func (vm *VM) traceRpc(url, method string, params, result interface{}, err error) {
+	ex := &Exchange{Kind: "rpc", Method: method, URL: url}
+	bs, _ := json.Marshal(params)
+	ex.Request = string(bs)
+	if err != nil {
+		ex.Err = err.Error()
+	} else {
+		bs, _ = json.Marshal(result)
+		ex.Response = string(bs)
+	}
+	vm.traceExchange(ex)
+}
@@ -0,0 +1,71 @@
+package neotest
+
+import (
+	"github.com/hzxiao/goutil/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestVM_Trace(t *testing.T) {
+	text := `let @a 1
+if (( $(a) == 1 ))
+    expect equal $(a) 2
+end`
+	cmds, err := newSourceByBytes([]byte(text)).Parse()
+	assert.NoError(t, err)
+
+	vm := NewVM(cmds)
+	vm.Trace = true
+	err = vm.Run()
+	assert.NoError(t, err)
+
+	steps := vm.Steps()
+	assert.Equal(t, 3, len(steps))
+	assert.Equal(t, "let @a 1", steps[0].Text)
+	assert.Equal(t, "if", steps[1].Cmd)
+	assert.Equal(t, 0, steps[1].Depth)
+	assert.Equal(t, "expect equal $(a) 2", steps[2].Text)
+	assert.Equal(t, 1, steps[2].Depth)
+	assert.Equal(t, 1, len(steps[2].Assertions))
+	assert.False(t, steps[2].Assertions[0].Pass)
+
+	vm = NewVM(cmds)
+	err = vm.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(vm.Steps()))
+}
+
+func TestVM_TraceRpc(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"state":"HALT, BREAK","gas_consumed":"0.1","stack":[]}}`))
+	}))
+	defer node.Close()
+
+	cmds, err := newSourceByBytes([]byte(`tx "test"
+tx-invokescript "51"
+tx-invoke-test "` + node.URL + `"`)).Parse()
+	assert.NoError(t, err)
+
+	//every vm records its own exchanges while running concurrently
+	vms := make([]*VM, 4)
+	var wg sync.WaitGroup
+	for i := range vms {
+		vms[i] = NewVM(cmds)
+		vms[i].Trace = true
+		wg.Add(1)
+		go func(vm *VM) {
+			defer wg.Done()
+			assert.NoError(t, vm.Run())
+		}(vms[i])
+	}
+	wg.Wait()
+	for _, vm := range vms {
+		steps := vm.Steps()
+		assert.Equal(t, 3, len(steps))
+		assert.Equal(t, 1, len(steps[2].Exchanges))
+		assert.Equal(t, "invokescript", steps[2].Exchanges[0].Method)
+		assert.Equal(t, `["51"]`, steps[2].Exchanges[0].Request)
+	}
+}
@@ -3,8 +3,12 @@ package neotest
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/CityOfZion/neo-go/pkg/core/transaction"
 	"github.com/hzxiao/goutil"
 	"github.com/hzxiao/neotest/pkg/neo"
+	"github.com/hzxiao/neotest/pkg/pln"
+	"io"
+	"os"
 	"strings"
 	"time"
 )
@@ -13,18 +17,59 @@ var (
 	ErrVariableUndefine = fmt.Errorf("variable undefine")
 )
 
+const maxScopeDepth = 1000
+
+//varPathReplacer convert path of variable into path of map, e.g. a.b[0] to a/b/0
+var varPathReplacer = strings.NewReplacer(".", "/", "[", "/", "]", "")
+
+//ExecError error occurred on executing a command
+type ExecError struct {
+	File string
+	Line int
+	Cmd  string
+	Err  error
+}
+
+func (e *ExecError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("file %v: line %v: exec %v err: %v", e.File, e.Line, e.Cmd, e.Err)
+	}
+	return fmt.Sprintf("line %v: exec %v err: %v", e.Line, e.Cmd, e.Err)
+}
+
 var internalVarMap = goutil.Map{
 	"neotest": goutil.Map{
 		"version": "0.1",
 		"author":  "hz",
 	},
-	"resp": nil,
-	"tx":   nil,
+	"resp":   nil,
+	"tx":     nil,
+	"invoke": nil,
 }
 
 type VM struct {
-	variable goutil.Map
-	commands []Commander
+	variable   goutil.Map
+	scopes     []goutil.Map
+	commands   []Commander
+	assertions []*Assertion
+	cases      []*CaseResult
+	txHashes   []string
+	steps      []*Step
+	stepStack  []*Step
+	setup      []Commander
+	teardown   []Commander
+	rowLabel   string //label of current row of file data, e.g. [row 1]
+	depth      int    //depth of executing command
+	hook       func(cmd Commander, depth int) error
+
+	//File source file of current executing command
+	File string
+	//CaseFilter only the cases whose name is accepted run if it's set
+	CaseFilter func(name string) bool
+	//Out output of commands such as echo, default is stdout
+	Out io.Writer
+	//Trace record every executed command with its tx and http exchanges as steps
+	Trace bool
 
 	CurHttpReq *HttpRequest
 	CurTx      *neo.Tx
@@ -34,6 +79,7 @@ func NewVM(commands []Commander) *VM {
 	vm := &VM{
 		variable: goutil.Map{},
 		commands: commands,
+		Out:      os.Stdout,
 	}
 
 	for k, v := range internalVarMap {
@@ -43,12 +89,13 @@ func NewVM(commands []Commander) *VM {
 }
 
 func (vm *VM) Var(ID string) (interface{}, bool) {
-	ID = strings.Replace(ID, ".", "/", -1)
+	ID = varPathReplacer.Replace(ID)
+	variable := vm.scope(strings.Split(ID, "/")[0])
 	if strings.Contains(ID, "/") {
-		v, _ := vm.variable.GetP(ID)
+		v, _ := variable.GetP(ID)
 		return v, v != nil
 	}
-	v, ok := vm.variable[ID]
+	v, ok := variable[ID]
 	if !ok {
 		return nil, ok
 	}
@@ -80,10 +127,35 @@ func (vm *VM) BoolV(ID string) (bool, bool) {
 }
 
 func (vm *VM) StoreVar(ID string, v interface{}) error {
-	vm.variable.Set(ID, v)
+	vm.scope(ID).Set(ID, v)
 	return nil
 }
 
+//scope get the variables which ID belongs to, it's the current local scope if declared there, or global
+func (vm *VM) scope(ID string) goutil.Map {
+	if len(vm.scopes) > 0 {
+		local := vm.scopes[len(vm.scopes)-1]
+		if _, ok := local[ID]; ok {
+			return local
+		}
+	}
+	return vm.variable
+}
+
+//pushScope enter a local scope with the given variables, e.g. arguments of procedure
+func (vm *VM) pushScope(variable goutil.Map) error {
+	if len(vm.scopes) >= maxScopeDepth {
+		return fmt.Errorf("scope depth exceeds %v", maxScopeDepth)
+	}
+	vm.scopes = append(vm.scopes, variable)
+	return nil
+}
+
+//popScope leave current local scope
+func (vm *VM) popScope() {
+	vm.scopes = vm.scopes[:len(vm.scopes)-1]
+}
+
 func (vm *VM) VarByType(ID string, typ string) (interface{}, error) {
 	v, exist := vm.Var(ID)
 	if !exist {
@@ -99,6 +171,15 @@ func (vm *VM) VarByType(ID string, typ string) (interface{}, error) {
 //SendHttp send current http request
 func (vm *VM) SendHttp() error {
 	code, header, body, err := vm.CurHttpReq.Send()
+	if vm.Trace {
+		ex := &Exchange{Kind: "http", Method: vm.CurHttpReq.Method, URL: vm.CurHttpReq.URL, Request: vm.CurHttpReq.Body, Code: code}
+		if err != nil {
+			ex.Err = err.Error()
+		} else {
+			ex.Response = goutil.String(body)
+		}
+		vm.traceExchange(ex)
+	}
 	if err != nil {
 		return err
 	}
@@ -126,21 +207,27 @@ func (vm *VM) SendTx(node string) error {
 		return fmt.Errorf("tx is nil")
 	}
 
-	err := neo.RelayTx(vm.CurTx, node)
+	err := neo.RelayTx(vm.CurTx, vm.rpcClient(node))
 	if err != nil {
 		return err
 	}
 
+	vm.txHashes = append(vm.txHashes, vm.CurTx.Hash().String())
 	return nil
 }
 
+//TxHashes hashes of txs sent by vm
+func (vm *VM) TxHashes() []string {
+	return vm.txHashes
+}
+
 func (vm *VM) WaitTx(node string) error {
 	ticker := time.NewTicker(time.Second * 2)
 	defer ticker.Stop()
+	var tx goutil.Map
 	for {
 		<-ticker.C
-		var tx goutil.Map
-		err := neo.Rpc(node, "getrawtransaction", []interface{}{vm.CurTx.Hash(), 1}, &tx)
+		err := vm.rpcClient(node).Call("getrawtransaction", []interface{}{vm.CurTx.Hash(), 1}, &tx)
 		if err != nil {
 			if strings.Contains(err.Error(), "Unknown transaction") {
 				continue
@@ -151,17 +238,82 @@ func (vm *VM) WaitTx(node string) error {
 		break
 	}
 
+	//only invocation tx has application log
+	if vm.CurTx.Type == transaction.InvocationType {
+		applog, err := vm.waitAppLog(node, ticker.C)
+		if err != nil {
+			pln.FWarn(vm.Out, "application log of tx %v is unavailable: %v", vm.CurTx.Label(), err)
+			applog = goutil.Map{"error": err.Error()}
+		}
+		tx.Set("applog", applog)
+		vm.StoreVar("tx", tx)
+	}
+
 	//clear cur tx
 	vm.CurTx = nil
 	return nil
 }
 
+//waitAppLog wait for application log of cur tx until it's executed
+func (vm *VM) waitAppLog(node string, tick <-chan time.Time) (goutil.Map, error) {
+	for {
+		applog, err := neo.GetApplicationLog(vm.rpcClient(node), vm.CurTx.Hash().String())
+		if err != nil && strings.Contains(err.Error(), "Unknown transaction") {
+			<-tick
+			continue
+		}
+		return applog, err
+	}
+}
+
 func (vm *VM) Run() error {
+	data, _ := findData(vm.commands)
+	if data == nil {
+		return vm.execCommands(vm.commands)
+	}
+
+	//run file once per row, each row is recorded as a case unless it has cases without error
+	for i, row := range data.rows {
+		vm.rowLabel = fmt.Sprintf("[row %v]", i+1)
+		n := len(vm.cases)
+		result := vm.runCase(vm.File+vm.rowLabel, data.line, row, func() error {
+			return vm.execCommands(vm.commands)
+		})
+		if len(vm.cases) == n || result.Err != nil {
+			//assertions of cases are recorded in their own results
+			if len(vm.cases) > n {
+				inCase := make(map[*Assertion]bool)
+				for _, c := range vm.cases[n:] {
+					for _, a := range c.Assertions {
+						inCase[a] = true
+					}
+				}
+				var assertions []*Assertion
+				for _, a := range result.Assertions {
+					if !inCase[a] {
+						assertions = append(assertions, a)
+					}
+				}
+				result.Assertions = assertions
+			}
+			vm.cases = append(vm.cases, result)
+		}
+		vm.setup, vm.teardown = nil, nil
+	}
+	vm.rowLabel = ""
+	return nil
+}
+
+//execCommands exec commands in order, it's also used by block commands to run their body
+func (vm *VM) execCommands(commands []Commander) error {
 	var err error
-	for _, cmd := range vm.commands {
-		err = cmd.Exec(vm)
+	for _, cmd := range commands {
+		err = vm.execCommand(cmd)
 		if err != nil {
-			return fmt.Errorf("line %v: exec %v err: %v", cmd.Line(), cmd.Name(), err)
+			if _, ok := err.(*ExecError); ok {
+				return err
+			}
+			return &ExecError{Line: cmd.Line(), Cmd: cmd.Name(), Err: err}
 		}
 	}
 	return nil
@@ -169,13 +321,13 @@ func (vm *VM) Run() error {
 
 //CheckInternalVarID check whether ID is internal var and validation
 func CheckInternalVarID(ID string) (bool, error) {
-	if !strings.Contains(ID, ".") {
+	root := varRoot(ID)
+	if root == ID {
 		return false, nil
 	}
 
-	fields := strings.Split(ID, ".")
-	if _, ok := internalVarMap[fields[0]]; !ok {
-		return true, fmt.Errorf("unknown internal variable: %v", fields[0])
+	if _, ok := internalVarMap[root]; !ok {
+		return true, fmt.Errorf("unknown internal variable: %v", root)
 	}
 
 	return true, nil